@@ -0,0 +1,84 @@
+package mikku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+var errNoAuditLog = errors.New("audit log is not available, set MIKKU_AUDIT_LOG")
+
+// auditEntry represents an operation recorded in the audit log
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	// Details describes how the operation was done
+	Details map[string]string `json:"details,omitempty"`
+	// Error is set if the operation failed
+	Error string `json:"error,omitempty"`
+}
+
+// auditLogPath returns MIKKU_AUDIT_LOG or <user config dir>/mikku/audit.log if it is not set
+// The directory is created beforehand so that the entry can be appended after the operation.
+func auditLogPath(cfg *Config) (string, error) {
+	if cfg.AuditLog != "" {
+		return cfg.AuditLog, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("find config directory: %w", err)
+	}
+	dir = filepath.Join(dir, "mikku")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create %s: %w", dir, err)
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// appendAuditLog appends the entry to the file as a JSON line
+// If path is empty, the entry is not recorded.
+func appendAuditLog(path string, entry *auditEntry) error {
+	if path == "" {
+		return nil
+	}
+
+	if entry.Actor == "" {
+		entry.Actor = currentActor()
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", path, err)
+	}
+	return nil
+}
+
+// currentActor returns the GitHub Actions actor or the OS user running mikku
+func currentActor() string {
+	if actor := os.Getenv("GITHUB_ACTOR"); actor != "" {
+		return actor
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
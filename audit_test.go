@@ -0,0 +1,84 @@
+package mikku
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_appendAuditLog(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	at := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entries := []*auditEntry{
+		{Time: at, Actor: "test-owner", Action: "yank", Repository: "test-repo", Tag: "v1.0.1", Details: map[string]string{"delete": "true"}},
+		{Time: at, Actor: "test-owner", Action: "yank", Repository: "test-repo", Tag: "v1.0.2", Error: "release not found"},
+	}
+	for _, e := range entries {
+		if err := appendAuditLog(path, e); err != nil {
+			t.Fatalf("appendAuditLog() error = %v", err)
+		}
+	}
+
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2019-01-01T00:00:00Z","actor":"test-owner","action":"yank","repository":"test-repo","tag":"v1.0.1","details":{"delete":"true"}}
+{"time":"2019-01-01T00:00:00Z","actor":"test-owner","action":"yank","repository":"test-repo","tag":"v1.0.2","error":"release not found"}
+`
+	if string(got) != want {
+		t.Errorf("appendAuditLog() = %s, want %s", got, want)
+	}
+}
+
+func Test_auditLogPath(t *testing.T) {
+	configHome := t.TempDir()
+	old, ok := os.LookupEnv("XDG_CONFIG_HOME")
+	_ = os.Setenv("XDG_CONFIG_HOME", configHome)
+	defer func() {
+		if ok {
+			_ = os.Setenv("XDG_CONFIG_HOME", old)
+		} else {
+			_ = os.Unsetenv("XDG_CONFIG_HOME")
+		}
+	}()
+
+	tests := []struct {
+		name string
+		cfg  *Config
+		want string
+	}{
+		{
+			name: "MIKKU_AUDIT_LOG",
+			cfg:  &Config{AuditLog: "/var/log/mikku.log"},
+			want: "/var/log/mikku.log",
+		},
+		{
+			name: "default",
+			cfg:  &Config{},
+			want: filepath.Join(configHome, "mikku", "audit.log"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditLogPath(tt.cfg)
+			if err != nil {
+				t.Fatalf("auditLogPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("auditLogPath() = %s, want %s", got, tt.want)
+			}
+			if tt.cfg.AuditLog != "" {
+				return
+			}
+			if err := appendAuditLog(got, &auditEntry{Action: "yank"}); err != nil {
+				t.Errorf("appendAuditLog() error = %v, want the directory to be created", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultBatchConcurrency = 4
+)
+
+var (
+	errBatchFailed = errors.New("some releases failed")
+)
+
+// ReleaseTarget represents a repository and its bump type released by `mikku release-batch`
+type ReleaseTarget struct {
+	Repository string `yaml:"repository"`
+	// Bump is major, minor, patch or a version
+	Bump string `yaml:"bump"`
+}
+
+// batchFile represents the content of the batch release file
+type batchFile struct {
+	Releases []*ReleaseTarget `yaml:"releases"`
+}
+
+// BatchReleaseOptions represents optional settings of `mikku release-batch` command
+type BatchReleaseOptions struct {
+	ReleaseOptions
+	// Concurrency is the number of releases running at the same time
+	Concurrency int
+	// ContinueOnError keeps releasing the other repositories after a release failed
+	// Otherwise, no more releases are started after the first failure.
+	ContinueOnError bool
+	// InferDependencies adds go.mod requirements between the repositories to the dependency graph
+	InferDependencies bool
+	// BumpDependencies opens pull requests updating go.mod of dependents to the new tags instead of releasing them
+	BumpDependencies bool
+}
+
+// batchStatus represents the result status of each release in the batch
+type batchStatus string
+
+const (
+	batchStatusReleased batchStatus = "released"
+	batchStatusFailed   batchStatus = "failed"
+	batchStatusSkipped  batchStatus = "skipped"
+	// batchStatusPending means a pull request bumping the dependencies was opened instead of releasing
+	batchStatusPending batchStatus = "pending"
+)
+
+// batchResult represents the result of each release in the batch
+type batchResult struct {
+	target *ReleaseTarget
+	status batchStatus
+	tag    string
+	url    string
+	err    error
+}
+
+// readBatchFile reads release targets from the YAML file
+func readBatchFile(path string) ([]*ReleaseTarget, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	bf := &batchFile{}
+	if err := yaml.Unmarshal(b, bf); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for i, target := range bf.Releases {
+		if target == nil || target.Repository == "" || target.Bump == "" {
+			return nil, fmt.Errorf("releases[%d]: repository and bump should be set", i)
+		}
+	}
+	if err := validateReleaseTargets(bf.Releases); err != nil {
+		return nil, err
+	}
+	return bf.Releases, nil
+}
+
+// parseReleaseTargets parses arguments formatted as `repository:bump`
+func parseReleaseTargets(args []string) ([]*ReleaseTarget, error) {
+	targets := make([]*ReleaseTarget, 0, len(args))
+	for _, arg := range args {
+		idx := strings.LastIndex(arg, ":")
+		if idx <= 0 || idx == len(arg)-1 {
+			return nil, fmt.Errorf("%s: argument should be formatted as repository:bump", arg)
+		}
+		targets = append(targets, &ReleaseTarget{Repository: arg[:idx], Bump: arg[idx+1:]})
+	}
+	if err := validateReleaseTargets(targets); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// validateReleaseTargets checks that each repository is released at most once
+func validateReleaseTargets(targets []*ReleaseTarget) error {
+	seen := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		if seen[target.Repository] {
+			return fmt.Errorf("%s: repository is listed more than once", target.Repository)
+		}
+		seen[target.Repository] = true
+	}
+	return nil
+}
+
+// ReleaseBatch is the entry point of `mikku release-batch` command
+// The releases share the GitHub client, so they also share the rate limit.
+// Repositories are released after the repositories they depend on.
+func ReleaseBatch(ctx context.Context, targets []*ReleaseTarget, opts BatchReleaseOptions) error {
+	if err := validateNotesSource(opts.NotesSource); err != nil {
+		return fmt.Errorf("release-batch: %w", err)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("release-batch: %w", err)
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	var modules map[string]*goModule
+	if opts.InferDependencies || opts.BumpDependencies {
+		modules, err = readGoModules(ctx, svc, targets)
+		if err != nil {
+			return fmt.Errorf("release-batch: read go.mod: %w", err)
+		}
+	}
+
+	graph := buildDependencyGraph(cfg, targets, modules)
+	stages, err := sortTargets(targets, graph)
+	if err != nil {
+		return fmt.Errorf("release-batch: %w", err)
+	}
+
+	resultByRepo := runBatchInOrder(ctx, stages, graph, opts.Concurrency, opts.ContinueOnError, func(ctx context.Context, target *ReleaseTarget, deps []*batchResult) (*batchResult, error) {
+		if m, ok := modules[target.Repository]; ok && opts.BumpDependencies {
+			bumps := make([]*dependencyBump, 0, len(deps))
+			for _, dep := range deps {
+				if depModule, ok := modules[dep.target.Repository]; ok {
+					bumps = append(bumps, &dependencyBump{repo: dep.target.Repository, module: depModule.path, tag: dep.tag})
+				}
+			}
+			url, err := openDependencyBumpPullRequest(ctx, svc, target.Repository, m, bumps)
+			if err != nil {
+				return nil, fmt.Errorf("bump dependencies: %w", err)
+			}
+			if url != "" {
+				return &batchResult{status: batchStatusPending, url: url}, nil
+			}
+		}
+
+		newRelease, err := release(ctx, cfg, svc, ioutil.Discard, nil, target.Repository, target.Bump, opts.ReleaseOptions)
+		if err != nil {
+			return nil, err
+		}
+		return &batchResult{tag: newRelease.GetTagName(), url: newRelease.GetHTMLURL()}, nil
+	})
+
+	results := make([]*batchResult, 0, len(targets))
+	for _, target := range targets {
+		results = append(results, resultByRepo[target.Repository])
+	}
+	printBatchSummary(os.Stdout, results)
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("release-batch: %w", err)
+	}
+	for _, r := range results {
+		if r.status == batchStatusFailed {
+			return errBatchFailed
+		}
+	}
+	return nil
+}
+
+// runBatchInOrder runs fn for each stage in order
+// fn receives the results of the dependencies of the target.
+// A target is skipped if any of its dependencies was not released.
+func runBatchInOrder(ctx context.Context, stages [][]*ReleaseTarget, graph dependencyGraph, concurrency int, continueOnError bool,
+	fn func(context.Context, *ReleaseTarget, []*batchResult) (*batchResult, error)) map[string]*batchResult {
+	results := make(map[string]*batchResult)
+	failed := false
+
+	for _, stage := range stages {
+		runnable := make([]*ReleaseTarget, 0, len(stage))
+		for _, target := range stage {
+			results[target.Repository] = &batchResult{target: target, status: batchStatusSkipped}
+			if failed && !continueOnError {
+				continue
+			}
+			if dep := notReleasedDependency(results, graph[target.Repository]); dep != "" {
+				results[target.Repository].err = fmt.Errorf("dependency %s was not released", dep)
+				continue
+			}
+			runnable = append(runnable, target)
+		}
+
+		stageResults := runBatch(ctx, runnable, concurrency, continueOnError, func(ctx context.Context, target *ReleaseTarget) (*batchResult, error) {
+			deps := make([]*batchResult, 0, len(graph[target.Repository]))
+			for _, dep := range graph[target.Repository] {
+				deps = append(deps, results[dep])
+			}
+			return fn(ctx, target, deps)
+		})
+		for _, r := range stageResults {
+			results[r.target.Repository] = r
+			if r.status == batchStatusFailed {
+				failed = true
+			}
+		}
+	}
+	return results
+}
+
+// notReleasedDependency returns the first dependency which was not released
+func notReleasedDependency(results map[string]*batchResult, deps []string) string {
+	for _, dep := range deps {
+		if r, ok := results[dep]; !ok || r.status != batchStatusReleased {
+			return dep
+		}
+	}
+	return ""
+}
+
+// runBatch runs fn for each target with the bounded number of workers
+// The results are returned in the same order as the targets.
+func runBatch(ctx context.Context, targets []*ReleaseTarget, concurrency int, continueOnError bool, fn func(context.Context, *ReleaseTarget) (*batchResult, error)) []*batchResult {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	results := make([]*batchResult, len(targets))
+	for i, target := range targets {
+		results[i] = &batchResult{target: target, status: batchStatusSkipped}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	jobs := make(chan int)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				mu.Lock()
+				stop := failed && !continueOnError
+				mu.Unlock()
+				if stop || ctx.Err() != nil {
+					continue
+				}
+
+				res, err := fn(ctx, targets[i])
+				if err != nil {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+					results[i].status = batchStatusFailed
+					results[i].err = err
+					continue
+				}
+				results[i].status = batchStatusReleased
+				if res.status != "" {
+					results[i].status = res.status
+				}
+				results[i].tag = res.tag
+				results[i].url = res.url
+			}
+		}()
+	}
+
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// printBatchSummary prints the results as a table
+func printBatchSummary(w io.Writer, results []*batchResult) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPOSITORY\tSTATUS\tTAG\tDETAIL")
+	for _, r := range results {
+		detail := r.url
+		if r.err != nil {
+			detail = r.err.Error()
+		}
+		tag := r.tag
+		if tag == "" {
+			tag = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.target.Repository, r.status, tag, detail)
+	}
+	_ = tw.Flush()
+}
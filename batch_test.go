@@ -0,0 +1,257 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_parseReleaseTargets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []*ReleaseTarget
+		wantErr bool
+	}{
+		{
+			name: "bump types and version",
+			args: []string{"service-a:minor", "service-b:v2.0.0"},
+			want: []*ReleaseTarget{
+				{Repository: "service-a", Bump: "minor"},
+				{Repository: "service-b", Bump: "v2.0.0"},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "no bump type",
+			args:    []string{"service-a"},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "empty bump type",
+			args:    []string{"service-a:"},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReleaseTargets(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseReleaseTargets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("parseReleaseTargets() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_readBatchFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "releases.yml")
+	content := `
+releases:
+  - repository: service-a
+    bump: minor
+  - repository: service-b
+    bump: patch
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readBatchFile(path)
+	if err != nil {
+		t.Fatalf("readBatchFile() error = %v", err)
+	}
+	want := []*ReleaseTarget{
+		{Repository: "service-a", Bump: "minor"},
+		{Repository: "service-b", Bump: "patch"},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("readBatchFile() diff=%s", cmp.Diff(got, want))
+	}
+}
+
+func Test_runBatch(t *testing.T) {
+	t.Parallel()
+
+	targets := []*ReleaseTarget{
+		{Repository: "service-a", Bump: "patch"},
+		{Repository: "service-b", Bump: "patch"},
+		{Repository: "service-c", Bump: "patch"},
+		{Repository: "service-d", Bump: "patch"},
+	}
+	errRelease := errors.New("release failed")
+
+	tests := []struct {
+		name            string
+		concurrency     int
+		continueOnError bool
+		want            []batchStatus
+	}{
+		{
+			name:            "fail fast",
+			concurrency:     1,
+			continueOnError: false,
+			want:            []batchStatus{batchStatusReleased, batchStatusFailed, batchStatusSkipped, batchStatusSkipped},
+		},
+		{
+			name:            "continue on error",
+			concurrency:     2,
+			continueOnError: true,
+			want:            []batchStatus{batchStatusReleased, batchStatusFailed, batchStatusReleased, batchStatusReleased},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning int32
+			results := runBatch(context.Background(), targets, tt.concurrency, tt.continueOnError, func(ctx context.Context, target *ReleaseTarget) (*batchResult, error) {
+				n := atomic.AddInt32(&running, 1)
+				defer atomic.AddInt32(&running, -1)
+				for {
+					m := atomic.LoadInt32(&maxRunning)
+					if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+
+				if target.Repository == "service-b" {
+					return nil, errRelease
+				}
+				return &batchResult{tag: "v1.0.1", url: "https://github.com/test-owner/" + target.Repository}, nil
+			})
+
+			got := make([]batchStatus, 0, len(results))
+			for _, r := range results {
+				got = append(got, r.status)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("runBatch() diff=%s", cmp.Diff(got, tt.want))
+			}
+			if !errors.Is(results[1].err, errRelease) {
+				t.Errorf("runBatch() error = %v, want %v", results[1].err, errRelease)
+			}
+			if maxRunning > int32(tt.concurrency) {
+				t.Errorf("runBatch() ran %d releases at the same time, want at most %d", maxRunning, tt.concurrency)
+			}
+		})
+	}
+}
+
+func Test_printBatchSummary(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	printBatchSummary(buf, []*batchResult{
+		{target: &ReleaseTarget{Repository: "service-a"}, status: batchStatusReleased, tag: "v1.0.1", url: "https://github.com/test-owner/service-a/releases/tag/v1.0.1"},
+		{target: &ReleaseTarget{Repository: "service-b"}, status: batchStatusFailed, err: errors.New("release failed")},
+	})
+
+	want := `REPOSITORY  STATUS    TAG     DETAIL
+service-a   released  v1.0.1  https://github.com/test-owner/service-a/releases/tag/v1.0.1
+service-b   failed    -       release failed
+`
+	if buf.String() != want {
+		t.Errorf("printBatchSummary() = %q, want %q", buf.String(), want)
+	}
+}
+
+func Test_runBatchInOrder(t *testing.T) {
+	t.Parallel()
+
+	libCore := &ReleaseTarget{Repository: "lib-core", Bump: "minor"}
+	libHTTP := &ReleaseTarget{Repository: "lib-http", Bump: "minor"}
+	service := &ReleaseTarget{Repository: "service", Bump: "patch"}
+	tool := &ReleaseTarget{Repository: "tool", Bump: "patch"}
+	stages := [][]*ReleaseTarget{{libCore, tool}, {libHTTP}, {service}}
+	graph := dependencyGraph{
+		"lib-http": {"lib-core"},
+		"service":  {"lib-http"},
+	}
+
+	tests := []struct {
+		name    string
+		pending string
+		failed  string
+		want    map[string]batchStatus
+	}{
+		{
+			name: "release in order",
+			want: map[string]batchStatus{
+				"lib-core": batchStatusReleased,
+				"tool":     batchStatusReleased,
+				"lib-http": batchStatusReleased,
+				"service":  batchStatusReleased,
+			},
+		},
+		{
+			name:   "skip dependents of failed release",
+			failed: "lib-core",
+			want: map[string]batchStatus{
+				"lib-core": batchStatusFailed,
+				"tool":     batchStatusReleased,
+				"lib-http": batchStatusSkipped,
+				"service":  batchStatusSkipped,
+			},
+		},
+		{
+			name:    "skip dependents of pending release",
+			pending: "lib-http",
+			want: map[string]batchStatus{
+				"lib-core": batchStatusReleased,
+				"tool":     batchStatusReleased,
+				"lib-http": batchStatusPending,
+				"service":  batchStatusSkipped,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			released := make(map[string]bool)
+
+			results := runBatchInOrder(context.Background(), stages, graph, 2, true, func(ctx context.Context, target *ReleaseTarget, deps []*batchResult) (*batchResult, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, dep := range deps {
+					if !released[dep.target.Repository] {
+						t.Errorf("%s was released before %s", target.Repository, dep.target.Repository)
+					}
+				}
+
+				switch target.Repository {
+				case tt.failed:
+					return nil, errors.New("release failed")
+				case tt.pending:
+					return &batchResult{status: batchStatusPending, url: "https://github.com/test-owner/" + target.Repository + "/pull/1"}, nil
+				}
+				released[target.Repository] = true
+				return &batchResult{tag: "v1.0.0"}, nil
+			})
+
+			got := make(map[string]batchStatus, len(results))
+			for repo, r := range results {
+				got[repo] = r.status
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("runBatchInOrder() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
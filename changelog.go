@@ -0,0 +1,94 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.0.0/).
+`
+
+	changelogSectionTemplate = `## [{{ .Tag }}] - {{ .Date }}
+{{ range $i, $pr := .PullRequests }}
+- {{ $pr.Title }} (#{{ $pr.Number }}) by @{{ $pr.User.Login }}{{ end }}{{ if .DependencyUpdates }}
+- Dependency updates ({{ len .DependencyUpdates }}){{ end }}
+`
+
+	changelogDateLayout = "2006-01-02"
+)
+
+// generateChangelogChange prepends the section of the new tag to the changelog file on the base branch
+// If the changelog already contains the section, it returns nil.
+func generateChangelogChange(ctx context.Context, svc *githubClient, repo, path, newTag string, date time.Time, note *releaseNote) (*fileChange, error) {
+	changelog, err := svc.getFile(ctx, repo, path, baseBranch)
+	if err != nil && !errors.Is(err, errFileNotFound) {
+		return nil, fmt.Errorf("get changelog: %w", err)
+	}
+
+	if hasChangelogSection(changelog, newTag) {
+		return nil, nil
+	}
+
+	section, err := generateChangelogSection(newTag, date, note)
+	if err != nil {
+		return nil, fmt.Errorf("generate changelog section: %w", err)
+	}
+	return &fileChange{path: path, content: prependChangelogSection(changelog, section)}, nil
+}
+
+func generateChangelogSection(tag string, date time.Time, note *releaseNote) (string, error) {
+	tmpl, err := template.New("changelog").Parse(changelogSectionTemplate)
+	if err != nil {
+		return "", fmt.Errorf("template parse error: %w", err)
+	}
+
+	buff := bytes.NewBuffer([]byte{})
+
+	section := map[string]interface{}{
+		"Tag":               tag,
+		"Date":              date.Format(changelogDateLayout),
+		"PullRequests":      note.PullRequests,
+		"DependencyUpdates": note.DependencyUpdates,
+	}
+
+	if err := tmpl.Execute(buff, section); err != nil {
+		return "", fmt.Errorf("template execute error: %w", err)
+	}
+	return buff.String(), nil
+}
+
+// prependChangelogSection inserts the section above the latest released section
+// If the changelog is empty, the Keep a Changelog header is added
+func prependChangelogSection(changelog, section string) string {
+	if strings.TrimSpace(changelog) == "" {
+		return changelogHeader + "\n" + section
+	}
+
+	lines := strings.SplitAfter(changelog, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## [") && !strings.HasPrefix(line, "## [Unreleased]") {
+			return strings.Join(lines[:i], "") + section + "\n" + strings.Join(lines[i:], "")
+		}
+	}
+	return strings.TrimRight(changelog, "\n") + "\n\n" + section
+}
+
+// hasChangelogSection reports whether the changelog already contains the section of the tag
+func hasChangelogSection(changelog, tag string) bool {
+	for _, line := range strings.Split(changelog, "\n") {
+		if strings.HasPrefix(line, "## ["+tag+"]") {
+			return true
+		}
+	}
+	return false
+}
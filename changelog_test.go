@@ -0,0 +1,166 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_generateChangelogSection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		tag  string
+		prs  []*github.PullRequest
+		want string
+	}{
+		{
+			name: "No Pull Requests",
+			tag:  "v1.0.0",
+			prs:  nil,
+			want: `## [v1.0.0] - 2020-01-02
+
+`,
+		},
+		{
+			name: "One Pull Request",
+			tag:  "v1.0.1",
+			prs: []*github.PullRequest{
+				{
+					Number: github.Int(1),
+					Title:  github.String("Pull Request Title"),
+					User: &github.User{
+						Login: github.String("test-owner"),
+					},
+				},
+			},
+			want: `## [v1.0.1] - 2020-01-02
+
+- Pull Request Title (#1) by @test-owner
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := generateChangelogSection(tt.tag, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), &releaseNote{PullRequests: tt.prs})
+			if err != nil {
+				t.Errorf("generateChangelogSection() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("generateChangelogSection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_prependChangelogSection(t *testing.T) {
+	t.Parallel()
+
+	section := "## [v1.1.0] - 2020-01-02\n\n- New (#2) by @test-owner\n"
+
+	tests := []struct {
+		name      string
+		changelog string
+		want      string
+	}{
+		{
+			name:      "empty changelog",
+			changelog: "",
+			want:      changelogHeader + "\n" + section,
+		},
+		{
+			name:      "insert above the latest section",
+			changelog: "# Changelog\n\n## [v1.0.0] - 2020-01-01\n\n- Old (#1) by @test-owner\n",
+			want:      "# Changelog\n\n## [v1.1.0] - 2020-01-02\n\n- New (#2) by @test-owner\n\n## [v1.0.0] - 2020-01-01\n\n- Old (#1) by @test-owner\n",
+		},
+		{
+			name:      "keep unreleased section on top",
+			changelog: "# Changelog\n\n## [Unreleased]\n\n## [v1.0.0] - 2020-01-01\n",
+			want:      "# Changelog\n\n## [Unreleased]\n\n## [v1.1.0] - 2020-01-02\n\n- New (#2) by @test-owner\n\n## [v1.0.0] - 2020-01-01\n",
+		},
+		{
+			name:      "no released section",
+			changelog: "# Changelog\n",
+			want:      "# Changelog\n\n## [v1.1.0] - 2020-01-02\n\n- New (#2) by @test-owner\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prependChangelogSection(tt.changelog, section); got != tt.want {
+				t.Errorf("prependChangelogSection() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hasChangelogSection(t *testing.T) {
+	t.Parallel()
+
+	changelog := "# Changelog\n\n## [v1.0.0] - 2020-01-01\n"
+
+	if !hasChangelogSection(changelog, "v1.0.0") {
+		t.Errorf("hasChangelogSection() = false, want true")
+	}
+	if hasChangelogSection(changelog, "v1.0.1") {
+		t.Errorf("hasChangelogSection() = true, want false")
+	}
+}
+
+func Test_generateChangelogChange(t *testing.T) {
+	t.Parallel()
+
+	notFoundResp := &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	tests := []struct {
+		name     string
+		injector func(*MockgitHubRepositoriesClient)
+		want     *fileChange
+	}{
+		{
+			name: "create new changelog",
+			injector: func(cli *MockgitHubRepositoriesClient) {
+				cli.EXPECT().GetContents(gomock.Any(), "test-owner", "test-repo", "CHANGELOG.md", gomock.Any()).
+					Return(nil, nil, notFoundResp, errors.New("404 not found"))
+			},
+			want: &fileChange{
+				path:    "CHANGELOG.md",
+				content: changelogHeader + "\n## [v1.0.0] - 2020-01-02\n\n",
+			},
+		},
+		{
+			name: "already contains the section",
+			injector: func(cli *MockgitHubRepositoriesClient) {
+				cli.EXPECT().GetContents(gomock.Any(), "test-owner", "test-repo", "CHANGELOG.md", gomock.Any()).
+					Return(&github.RepositoryContent{Content: github.String("## [v1.0.0] - 2020-01-02\n")}, nil, nil, nil)
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			cli := NewMockgitHubRepositoriesClient(ctrl)
+			tt.injector(cli)
+
+			s := newGitHubClient("test-owner", gitHubClients{repositories: cli})
+
+			got, err := generateChangelogChange(context.Background(), s, "test-repo", "CHANGELOG.md", "v1.0.0", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), &releaseNote{})
+			if err != nil {
+				t.Errorf("generateChangelogChange() error = %v", err)
+				return
+			}
+			if !cmp.Equal(got, tt.want, cmp.AllowUnexported(fileChange{})) {
+				t.Errorf("generateChangelogChange() diff=%s", cmp.Diff(got, tt.want, cmp.AllowUnexported(fileChange{})))
+			}
+		})
+	}
+}
@@ -1,13 +1,60 @@
 package mikku
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultWaitTimeout    = 10 * time.Minute
+)
+
 var mikkuVersion string
 
+// releaseFlags are flags shared by release commands
+var releaseFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "changelog-file",
+		Usage: "Prepend the release notes to the given changelog file and commit it before tagging",
+	},
+	&cli.StringFlag{
+		Name:  "notes-source",
+		Usage: "Source of the release body: github, mikku or both",
+		Value: notesSourceMikku,
+	},
+}
+
+// pullRequestFlags are flags shared by commands opening manifest pull requests
+var pullRequestFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "reviewer",
+		Usage: "Request a review from the user. It can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  "team-reviewer",
+		Usage: "Request a review from the team slug. It can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  "label",
+		Usage: "Add the label. It can be repeated",
+	},
+	&cli.StringSliceFlag{
+		Name:  "assignee",
+		Usage: "Assign the user. It can be repeated",
+	},
+	&cli.StringFlag{
+		Name:  "auto-merge",
+		Usage: "Enable auto-merge with the merge method: squash, merge or rebase. disabled turns off auto-merge of the config file",
+	},
+}
+
 var commandRelease = &cli.Command{
 	Name:    "release",
 	Aliases: []string{"r"},
@@ -23,32 +70,488 @@ var commandRelease = &cli.Command{
 	- minor : minor version up Ex. v1.0.1 → v1.1.0
 	- path : patch version up Ex. v1.1.0 → v2.0.0
 	- version : create tag with a given version Ex. v1.0.0
+
+	mikku release -i <repository>
+
+	Choose the version from the candidates, edit the release body in $EDITOR
+	and confirm before the release is created.
 	`,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:    "interactive",
+			Aliases: []string{"i"},
+			Usage:   "Choose the version, edit the release body and confirm interactively",
+		},
+	}, releaseFlags...),
 	Action: doRelease,
 }
 
+var commandReleaseBatch = &cli.Command{
+	Name:  "release-batch",
+	Usage: "Create tags and GitHub releases of multiple repositories concurrently",
+	UsageText: `
+	mikku release-batch --file <releases.yml>
+	mikku release-batch <repository:bump>...
+
+	Create tags and GitHub releases of multiple repositories concurrently.
+	The bump is major, minor, patch or a version like mikku release.
+	A summary table is printed after all releases finish.
+
+	Repositories are released after the repositories they depend on.
+	Dependencies are declared by dependsOn in the config file or inferred
+	from go.mod with --infer-dependencies.
+
+	The releases file is formatted as below.
+
+	releases:
+	  - repository: sample-repository
+	    bump: minor
+	`,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "YAML file listing repositories and bump types",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of releases running at the same time",
+			Value: defaultBatchConcurrency,
+		},
+		&cli.BoolFlag{
+			Name:  "continue-on-error",
+			Usage: "Keep releasing the other repositories after a release failed (default: stop starting new releases)",
+		},
+		&cli.BoolFlag{
+			Name:  "infer-dependencies",
+			Usage: "Release repositories after the repositories required in their go.mod",
+		},
+		&cli.BoolFlag{
+			Name:  "bump-dependencies",
+			Usage: "Open pull requests updating go.mod of dependents to the new tags instead of releasing them",
+		},
+	}, releaseFlags...),
+	Action: doReleaseBatch,
+}
+
+var commandStatus = &cli.Command{
+	Name:  "status",
+	Usage: "Show unreleased changes of repositories",
+	UsageText: `
+	mikku status <repository>...
+
+	Show the current tag, its age, the number of unreleased pull requests
+	and the suggested next version of each repository.
+	The next version is inferred from the pull requests:
+
+	- major : title like "feat!: ...", "BREAKING CHANGE" in body or label breaking-change
+	- minor : title like "feat: ..." or label feature or enhancement
+	- patch : otherwise
+	`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: table or json",
+			Value: statusFormatTable,
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of repositories checked at the same time",
+			Value: defaultBatchConcurrency,
+		},
+	},
+	Action: doStatus,
+}
+
+var commandChangelog = &cli.Command{
+	Name:  "changelog",
+	Usage: "Render release notes between tags without releasing",
+	UsageText: `
+	mikku changelog --from <ref> [--to <ref>] <repository>
+
+	Render release notes of pull requests merged after --from until --to.
+	The refs are tags, branches or commits. If --to is omitted, the notes
+	include pull requests merged until now.
+	`,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "Tag, branch or commit where the range starts (excluded)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "Tag, branch or commit where the range ends (default: now)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: markdown, html, plain or json",
+			Value: notesFormatMarkdown,
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Write the notes to the given file (default: stdout)",
+		},
+	},
+	Action: doChangelog,
+}
+
+var commandYank = &cli.Command{
+	Name:  "yank",
+	Usage: "Mark a release as yanked or delete it",
+	UsageText: `
+	mikku yank <repository> <tag>
+
+	Mark the release as yanked by editing its title and body with a warning.
+	With --delete, the release and the tag are deleted instead.
+	The operation is appended to MIKKU_AUDIT_LOG (default: <user config dir>/mikku/audit.log).
+	`,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "delete",
+			Usage: "Delete the release and the tag instead of marking the release as yanked",
+		},
+		&cli.BoolFlag{
+			Name:  "repoint-latest",
+			Usage: "Make the previous release the latest one by marking the yanked release as a pre-release",
+		},
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "Reason written in the yanked release and the audit log",
+		},
+		&cli.BoolFlag{
+			Name:    "yes",
+			Aliases: []string{"y"},
+			Usage:   "Skip the confirmation prompt",
+		},
+	},
+	Action: doYank,
+}
+
+var commandPullRequest = &cli.Command{
+	Name:  "pr",
+	Usage: "Open a pull request updating the image tag in the manifest repository",
+	UsageText: `
+	mikku pr <repository> [<tag>]
+
+	Open a pull request updating the image tag in the manifest repository.
+	The manifest repository, the image and the kustomize overlays are read from
+	manifest in the config file. If the tag is omitted, the tag of the latest
+	release is used.
+	`,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "env",
+			Usage: "Environment in manifest.environments of the config file to update",
+		},
+		&cli.StringSliceFlag{
+			Name:  "overlay",
+			Usage: "Kustomize overlay directory to update instead of the config file. It can be repeated",
+		},
+		&cli.BoolFlag{
+			Name:  "pin-digest",
+			Usage: "Resolve the digest of the tag from the container registry and pin the image by it",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-image-check",
+			Usage: "Skip checking that the image of the tag exists in the container registry",
+		},
+		&cli.BoolFlag{
+			Name:  "wait-for-image",
+			Usage: "Wait until the image of the tag is pushed to the container registry",
+		},
+		&cli.DurationFlag{
+			Name:  "wait-timeout",
+			Usage: "Give up waiting for the image after the given duration",
+			Value: defaultWaitTimeout,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print the diff of the manifests without opening the pull request",
+		},
+	}, pullRequestFlags...),
+	Action: doPullRequest,
+}
+
+var commandPromote = &cli.Command{
+	Name:  "promote",
+	Usage: "Open a pull request promoting the deployed image tag to another environment",
+	UsageText: `
+	mikku promote --from <environment> --to <environment> <repository>
+
+	Open a pull request writing the image tag deployed to --from into --to.
+	The environments are read from manifest.environments in the config file.
+	The pull request body lists the changelog between the tags of the environments.
+	`,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "Environment whose deployed tag is promoted",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "Environment the tag is written to",
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print the diff of the manifests without opening the pull request",
+		},
+	}, pullRequestFlags...),
+	Action: doPromote,
+}
+
 func doRelease(c *cli.Context) error {
 	if c.Args().Len() == 0 {
 		_ = cli.ShowCommandHelp(c, "release")
 		return nil
 	}
 
-	if c.Args().Len() != 2 {
+	interactive := c.Bool("interactive")
+	if interactive && c.Args().Len() != 1 {
+		return fmt.Errorf("One argument is required in interactive mode: repository")
+	}
+	if !interactive && c.Args().Len() != 2 {
 		return fmt.Errorf("Two arguments are required: reposiotry and bump type")
 	}
 
 	repo := c.Args().Get(0)
 	bumpTyp := c.Args().Get(1)
 
-	if err := Release(repo, bumpTyp); err != nil {
+	opts := ReleaseOptions{
+		ChangelogFile:  c.String("changelog-file"),
+		NotesSource:    c.String("notes-source"),
+		RequestTimeout: c.Duration("request-timeout"),
+		Interactive:    interactive,
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := Release(ctx, repo, bumpTyp, opts); err != nil {
 		return fmt.Errorf("Failed to execute release: %v", err)
 	}
 
 	return nil
 }
 
+func doReleaseBatch(c *cli.Context) error {
+	if c.Args().Len() == 0 && c.String("file") == "" {
+		_ = cli.ShowCommandHelp(c, "release-batch")
+		return nil
+	}
+
+	if c.Args().Len() > 0 && c.String("file") != "" {
+		return fmt.Errorf("Either --file or arguments can be specified")
+	}
+
+	var (
+		targets []*ReleaseTarget
+		err     error
+	)
+	if c.String("file") != "" {
+		targets, err = readBatchFile(c.String("file"))
+	} else {
+		targets, err = parseReleaseTargets(c.Args().Slice())
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid release targets: %v", err)
+	}
+
+	opts := BatchReleaseOptions{
+		ReleaseOptions: ReleaseOptions{
+			ChangelogFile:  c.String("changelog-file"),
+			NotesSource:    c.String("notes-source"),
+			RequestTimeout: c.Duration("request-timeout"),
+		},
+		Concurrency:       c.Int("concurrency"),
+		ContinueOnError:   c.Bool("continue-on-error"),
+		InferDependencies: c.Bool("infer-dependencies"),
+		BumpDependencies:  c.Bool("bump-dependencies"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := ReleaseBatch(ctx, targets, opts); err != nil {
+		return fmt.Errorf("Failed to execute release-batch: %v", err)
+	}
+
+	return nil
+}
+
+func doStatus(c *cli.Context) error {
+	if c.Args().Len() == 0 {
+		_ = cli.ShowCommandHelp(c, "status")
+		return nil
+	}
+
+	opts := StatusOptions{
+		Format:         c.String("format"),
+		Concurrency:    c.Int("concurrency"),
+		RequestTimeout: c.Duration("request-timeout"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := Status(ctx, c.Args().Slice(), opts); err != nil {
+		return fmt.Errorf("Failed to execute status: %v", err)
+	}
+
+	return nil
+}
+
+func doChangelog(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("One argument is required: repository")
+	}
+
+	opts := ChangelogOptions{
+		From:           c.String("from"),
+		To:             c.String("to"),
+		Format:         c.String("format"),
+		Output:         c.String("output"),
+		RequestTimeout: c.Duration("request-timeout"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := Changelog(ctx, c.Args().Get(0), opts); err != nil {
+		return fmt.Errorf("Failed to execute changelog: %v", err)
+	}
+
+	return nil
+}
+
+func doYank(c *cli.Context) error {
+	if c.Args().Len() == 0 {
+		_ = cli.ShowCommandHelp(c, "yank")
+		return nil
+	}
+
+	if c.Args().Len() != 2 {
+		return fmt.Errorf("Two arguments are required: repository and tag")
+	}
+
+	opts := YankOptions{
+		Delete:         c.Bool("delete"),
+		RepointLatest:  c.Bool("repoint-latest"),
+		Yes:            c.Bool("yes"),
+		Reason:         c.String("reason"),
+		RequestTimeout: c.Duration("request-timeout"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := Yank(ctx, c.Args().Get(0), c.Args().Get(1), opts); err != nil {
+		return fmt.Errorf("Failed to execute yank: %v", err)
+	}
+
+	return nil
+}
+
+func doPullRequest(c *cli.Context) error {
+	if c.Args().Len() == 0 {
+		_ = cli.ShowCommandHelp(c, "pr")
+		return nil
+	}
+
+	if c.Args().Len() > 2 {
+		return fmt.Errorf("Too many arguments: repository and tag are accepted")
+	}
+
+	opts := PullRequestOptions{
+		Tag:            c.Args().Get(1),
+		Environment:    c.String("env"),
+		Overlays:       c.StringSlice("overlay"),
+		PinDigest:      c.Bool("pin-digest"),
+		SkipImageCheck: c.Bool("skip-image-check"),
+		WaitForImage:   c.Bool("wait-for-image"),
+		WaitTimeout:    c.Duration("wait-timeout"),
+		DryRun:         c.Bool("dry-run"),
+		Settings:       pullRequestSettings(c),
+		RequestTimeout: c.Duration("request-timeout"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := PullRequest(ctx, c.Args().Get(0), opts); err != nil {
+		return fmt.Errorf("Failed to execute pr: %v", err)
+	}
+
+	return nil
+}
+
+func doPromote(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("One argument is required: repository")
+	}
+
+	opts := PromoteOptions{
+		From:           c.String("from"),
+		To:             c.String("to"),
+		DryRun:         c.Bool("dry-run"),
+		Settings:       pullRequestSettings(c),
+		RequestTimeout: c.Duration("request-timeout"),
+	}
+
+	ctx, cancel := commandContext(c)
+	defer cancel()
+
+	if err := Promote(ctx, c.Args().Get(0), opts); err != nil {
+		return fmt.Errorf("Failed to execute promote: %v", err)
+	}
+
+	return nil
+}
+
+// pullRequestSettings returns the settings given by pullRequestFlags
+func pullRequestSettings(c *cli.Context) *PullRequestConfig {
+	return &PullRequestConfig{
+		Reviewers:     c.StringSlice("reviewer"),
+		TeamReviewers: c.StringSlice("team-reviewer"),
+		Labels:        c.StringSlice("label"),
+		Assignees:     c.StringSlice("assignee"),
+		AutoMerge:     c.String("auto-merge"),
+	}
+}
+
+// commandContext returns the context of the command applying the global timeout
+func commandContext(c *cli.Context) (context.Context, context.CancelFunc) {
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		return context.WithTimeout(c.Context, timeout)
+	}
+	return context.WithCancel(c.Context)
+}
+
+// signalContext returns a context which is cancelled when the process receives an interrupt signal
+func signalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel()
+	}
+}
+
 // Run runs commands depending on the given argument
 func Run(args []string) error {
+	ctx, stop := signalContext(context.Background())
+	defer stop()
+
 	app := &cli.App{
 		Name:  "mikku",
 		Usage: "Bump Semantic Versioning tag andcreate GitHub release",
@@ -59,12 +562,29 @@ func Run(args []string) error {
 			},
 		},
 		Version: mikkuVersion,
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Abort the command after the given duration. Ex. 5m (default: no timeout)",
+			},
+			&cli.DurationFlag{
+				Name:  "request-timeout",
+				Usage: "Abort each GitHub API call after the given duration",
+				Value: defaultRequestTimeout,
+			},
+		},
 		Commands: []*cli.Command{
 			commandRelease,
+			commandReleaseBatch,
+			commandStatus,
+			commandChangelog,
+			commandYank,
+			commandPullRequest,
+			commandPromote,
 		},
 	}
 
-	if err := app.Run(args); err != nil {
+	if err := app.RunContext(ctx, args); err != nil {
 		return fmt.Errorf("ERROR: %w", err)
 	}
 	return nil
@@ -20,6 +20,81 @@ func TestRun(t *testing.T) {
 			args:    []string{"", "release", "mikku"},
 			wantErr: true,
 		},
+		{
+			name:    "release: invalid timeout",
+			args:    []string{"", "--timeout", "forever", "release", "mikku", "patch"},
+			wantErr: true,
+		},
+		{
+			name:    "release-batch: no arguments",
+			args:    []string{"", "release-batch"},
+			wantErr: false,
+		},
+		{
+			name:    "release-batch: invalid argument",
+			args:    []string{"", "release-batch", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "status: no arguments",
+			args:    []string{"", "status"},
+			wantErr: false,
+		},
+		{
+			name:    "status: invalid format",
+			args:    []string{"", "status", "--format", "yaml", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "changelog: no from",
+			args:    []string{"", "changelog", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "changelog: invalid format",
+			args:    []string{"", "changelog", "--from", "v1.0.0", "--format", "pdf", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "yank: no arguments",
+			args:    []string{"", "yank"},
+			wantErr: false,
+		},
+		{
+			name:    "yank: only one argument",
+			args:    []string{"", "yank", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "release: bump type in interactive mode",
+			args:    []string{"", "release", "-i", "mikku", "patch"},
+			wantErr: true,
+		},
+		{
+			name:    "pr: no arguments",
+			args:    []string{"", "pr"},
+			wantErr: false,
+		},
+		{
+			name:    "pr: too many arguments",
+			args:    []string{"", "pr", "mikku", "v1.0.0", "extra"},
+			wantErr: true,
+		},
+		{
+			name:    "promote: without environments",
+			args:    []string{"", "promote", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "promote: same environment",
+			args:    []string{"", "promote", "--from", "prod", "--to", "prod", "mikku"},
+			wantErr: true,
+		},
+		{
+			name:    "release: invalid notes source",
+			args:    []string{"", "release", "--notes-source", "unknown", "mikku", "patch"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
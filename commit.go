@@ -0,0 +1,102 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// releaseBranchPrefix is the prefix of the branch of the release pull request
+const releaseBranchPrefix = "mikku/release-"
+
+var (
+	// errReleasePullRequestOpened represents error that the release files could not be committed directly
+	// because the base branch is protected
+	errReleasePullRequestOpened = errors.New("release pull request was opened. merge it and run mikku release again")
+)
+
+// fileChange represents a file content to be committed
+type fileChange struct {
+	path    string
+	content string
+}
+
+// commitReleaseFiles commits the changes to the base branch in one commit and returns the SHA of the commit
+// which the new tag should point to.
+// If the base branch is protected, it opens a pull request instead and returns errReleasePullRequestOpened.
+// The branch and the pull request left by the previous run are reused, so the command can be retried.
+func commitReleaseFiles(ctx context.Context, svc *githubClient, repo, newTag string, changes []*fileChange) (string, error) {
+	message := releaseCommitMessage(newTag)
+
+	protected, err := svc.isProtectedBranch(ctx, repo, baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("check branch protection: %w", err)
+	}
+	if !protected {
+		commitSHA, err := svc.commitFiles(ctx, repo, baseBranch, message, changes)
+		if err != nil {
+			return "", fmt.Errorf("commit release files: %w", err)
+		}
+		return commitSHA, nil
+	}
+
+	branch := releaseBranchPrefix + newTag
+	open, err := svc.listOpenPullRequests(ctx, repo, baseBranch)
+	if err != nil {
+		return "", fmt.Errorf("list release pull requests: %w", err)
+	}
+
+	// The commit is always based on the base branch, so the branch left by the previous run is overwritten
+	if _, err := svc.forceCommitFiles(ctx, repo, baseBranch, branch, message, changes); err != nil {
+		return "", fmt.Errorf("commit release files: %w", err)
+	}
+	for _, pr := range open {
+		if pr.GetHead().GetLabel() == svc.owner+":"+branch {
+			return "", fmt.Errorf("%s: %w", pr.GetHTMLURL(), errReleasePullRequestOpened)
+		}
+	}
+	// The branch is left if creating the pull request fails. It is reused by the next run.
+	pr, err := svc.createPullRequest(ctx, repo, branch, baseBranch, message, generateReleasePullRequestBody(newTag, changes))
+	if err != nil {
+		return "", fmt.Errorf("create release pull request (branch %s was pushed): %w", branch, err)
+	}
+	return "", fmt.Errorf("%s: %w", pr.GetHTMLURL(), errReleasePullRequestOpened)
+}
+
+// excludeReleasePullRequests removes the release pull requests opened by mikku
+// They only update the release files, so they are not listed in the release notes.
+func excludeReleasePullRequests(prs []*github.PullRequest) []*github.PullRequest {
+	filtered := make([]*github.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		if strings.HasPrefix(pr.GetHead().GetRef(), releaseBranchPrefix) {
+			continue
+		}
+		filtered = append(filtered, pr)
+	}
+	return filtered
+}
+
+// releaseCommitMessage returns the message of the commit of the release files
+func releaseCommitMessage(tag string) string {
+	return "Release " + tag
+}
+
+// isReleaseCommitMessage reports whether the commit was made by mikku for the tag
+// The release pull request may be squashed, which appends the number such as `Release v1.0.0 (#12)`.
+func isReleaseCommitMessage(message, tag string) bool {
+	subject := strings.SplitN(message, "\n", 2)[0]
+	want := releaseCommitMessage(tag)
+	return subject == want || strings.HasPrefix(subject, want+" (#")
+}
+
+func generateReleasePullRequestBody(newTag string, changes []*fileChange) string {
+	body := fmt.Sprintf("Update the following files for %s.\n\n", newTag)
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, "- `"+c.path+"`")
+	}
+	return body + strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,189 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_commitReleaseFiles(t *testing.T) {
+	t.Parallel()
+
+	changes := []*fileChange{{path: "CHANGELOG.md", content: "# Changelog\n"}}
+
+	expectCommit := func(gitCli *MockgitHubGitClient, branch string) {
+		gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+branch).
+			Return(&github.Reference{Object: &github.GitObject{SHA: github.String("parent-sha")}}, nil, nil)
+		gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "parent-sha").
+			Return(&github.Commit{SHA: github.String("parent-sha"), Tree: &github.Tree{SHA: github.String("base-tree-sha")}}, nil, nil)
+		gitCli.EXPECT().CreateTree(gomock.Any(), "test-owner", "test-repo", "base-tree-sha", []*github.TreeEntry{{
+			Path:    github.String("CHANGELOG.md"),
+			Mode:    github.String("100644"),
+			Type:    github.String("blob"),
+			Content: github.String("# Changelog\n"),
+		}}).Return(&github.Tree{SHA: github.String("tree-sha")}, nil, nil)
+		gitCli.EXPECT().CreateCommit(gomock.Any(), "test-owner", "test-repo", &github.Commit{
+			Message: github.String("Release v1.0.0"),
+			Tree:    &github.Tree{SHA: github.String("tree-sha")},
+			Parents: []*github.Commit{{SHA: github.String("parent-sha")}},
+		}).Return(&github.Commit{SHA: github.String("commit-sha")}, nil, nil)
+		gitCli.EXPECT().UpdateRef(gomock.Any(), "test-owner", "test-repo", &github.Reference{
+			Ref:    github.String("refs/heads/" + branch),
+			Object: &github.GitObject{SHA: github.String("commit-sha")},
+		}, false).Return(nil, nil, nil)
+	}
+
+	// expectForceCommit expects the commit on top of the base branch. The release branch exists if ref is not nil.
+	expectForceCommit := func(gitCli *MockgitHubGitClient, ref *github.Reference) {
+		gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).
+			Return(&github.Reference{Object: &github.GitObject{SHA: github.String("parent-sha")}}, nil, nil)
+		gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "parent-sha").
+			Return(&github.Commit{SHA: github.String("parent-sha"), Tree: &github.Tree{SHA: github.String("base-tree-sha")}}, nil, nil)
+		gitCli.EXPECT().CreateTree(gomock.Any(), "test-owner", "test-repo", "base-tree-sha", gomock.Any()).
+			Return(&github.Tree{SHA: github.String("tree-sha")}, nil, nil)
+		gitCli.EXPECT().CreateCommit(gomock.Any(), "test-owner", "test-repo", gomock.Any()).
+			Return(&github.Commit{SHA: github.String("commit-sha")}, nil, nil)
+		if ref == nil {
+			gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/mikku/release-v1.0.0").
+				Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found"))
+			return
+		}
+		gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/mikku/release-v1.0.0").Return(ref, nil, nil)
+	}
+
+	tests := []struct {
+		name     string
+		injector func(*MockgitHubRepositoriesClient, *MockgitHubPullRequestsClient, *MockgitHubGitClient)
+		want     string
+		wantErr  error
+	}{
+		{
+			name: "commit to unprotected branch",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetBranch(gomock.Any(), "test-owner", "test-repo", baseBranch).
+					Return(&github.Branch{Protected: github.Bool(false)}, nil, nil)
+				expectCommit(gitCli, baseBranch)
+			},
+			want:    "commit-sha",
+			wantErr: nil,
+		},
+		{
+			name: "open pull request to protected branch",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetBranch(gomock.Any(), "test-owner", "test-repo", baseBranch).
+					Return(&github.Branch{Protected: github.Bool(true)}, nil, nil)
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, nil, nil)
+				expectForceCommit(gitCli, nil)
+				gitCli.EXPECT().CreateRef(gomock.Any(), "test-owner", "test-repo", &github.Reference{
+					Ref:    github.String("refs/heads/mikku/release-v1.0.0"),
+					Object: &github.GitObject{SHA: github.String("commit-sha")},
+				}).Return(nil, nil, nil)
+				prCli.EXPECT().Create(gomock.Any(), "test-owner", "test-repo", gomock.Any()).
+					Return(&github.PullRequest{HTMLURL: github.String("https://github.com/test-owner/test-repo/pull/1")}, nil, nil)
+			},
+			want:    "",
+			wantErr: errReleasePullRequestOpened,
+		},
+		{
+			name: "reuse branch and pull request of previous run",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetBranch(gomock.Any(), "test-owner", "test-repo", baseBranch).
+					Return(&github.Branch{Protected: github.Bool(true)}, nil, nil)
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return([]*github.PullRequest{
+					{
+						HTMLURL: github.String("https://github.com/test-owner/test-repo/pull/2"),
+						Head:    &github.PullRequestBranch{Label: github.String("test-owner:feature")},
+					},
+					{
+						HTMLURL: github.String("https://github.com/test-owner/test-repo/pull/1"),
+						Head:    &github.PullRequestBranch{Label: github.String("test-owner:mikku/release-v1.0.0")},
+					},
+				}, nil, nil)
+				expectForceCommit(gitCli, &github.Reference{Object: &github.GitObject{SHA: github.String("old-sha")}})
+				gitCli.EXPECT().UpdateRef(gomock.Any(), "test-owner", "test-repo", &github.Reference{
+					Ref:    github.String("refs/heads/mikku/release-v1.0.0"),
+					Object: &github.GitObject{SHA: github.String("commit-sha")},
+				}, true).Return(nil, nil, nil)
+			},
+			want:    "",
+			wantErr: errReleasePullRequestOpened,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			prCli := NewMockgitHubPullRequestsClient(ctrl)
+			gitCli := NewMockgitHubGitClient(ctrl)
+			tt.injector(repoCli, prCli, gitCli)
+
+			s := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, pullRequests: prCli, git: gitCli})
+
+			got, err := commitReleaseFiles(context.Background(), s, "test-repo", "v1.0.0", changes)
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("commitReleaseFiles() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("commitReleaseFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_commitReleaseFiles_reportsPushedBranch(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repoCli := NewMockgitHubRepositoriesClient(ctrl)
+	prCli := NewMockgitHubPullRequestsClient(ctrl)
+	gitCli := NewMockgitHubGitClient(ctrl)
+
+	repoCli.EXPECT().GetBranch(gomock.Any(), "test-owner", "test-repo", baseBranch).
+		Return(&github.Branch{Protected: github.Bool(true)}, nil, nil)
+	prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, nil, nil)
+	gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).
+		Return(&github.Reference{Object: &github.GitObject{SHA: github.String("base-sha")}}, nil, nil)
+	gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "base-sha").
+		Return(&github.Commit{SHA: github.String("base-sha"), Tree: &github.Tree{SHA: github.String("base-tree-sha")}}, nil, nil)
+	gitCli.EXPECT().CreateTree(gomock.Any(), "test-owner", "test-repo", "base-tree-sha", gomock.Any()).
+		Return(&github.Tree{SHA: github.String("tree-sha")}, nil, nil)
+	gitCli.EXPECT().CreateCommit(gomock.Any(), "test-owner", "test-repo", gomock.Any()).
+		Return(&github.Commit{SHA: github.String("commit-sha")}, nil, nil)
+	gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/mikku/release-v1.0.0").
+		Return(&github.Reference{Object: &github.GitObject{SHA: github.String("old-sha")}}, nil, nil)
+	gitCli.EXPECT().UpdateRef(gomock.Any(), "test-owner", "test-repo", gomock.Any(), true).Return(nil, nil, nil)
+	prCli.EXPECT().Create(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, nil, context.Canceled)
+
+	s := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, pullRequests: prCli, git: gitCli})
+
+	_, err := commitReleaseFiles(context.Background(), s, "test-repo", "v1.0.0", []*fileChange{{path: "CHANGELOG.md"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("commitReleaseFiles() error = %v, want %v", err, context.Canceled)
+	}
+	if !strings.Contains(err.Error(), "branch mikku/release-v1.0.0 was pushed") {
+		t.Errorf("commitReleaseFiles() error = %v, want to report the pushed branch", err)
+	}
+}
+
+func Test_excludeReleasePullRequests(t *testing.T) {
+	t.Parallel()
+
+	feature := &github.PullRequest{Number: github.Int(1), Head: &github.PullRequestBranch{Ref: github.String("feature")}}
+	releasePR := &github.PullRequest{Number: github.Int(2), Head: &github.PullRequestBranch{Ref: github.String("mikku/release-v1.0.0")}}
+	noHead := &github.PullRequest{Number: github.Int(3)}
+
+	got := excludeReleasePullRequests([]*github.PullRequest{feature, releasePR, noHead})
+	want := []*github.PullRequest{feature, noHead}
+	if !cmp.Equal(got, want) {
+		t.Errorf("excludeReleasePullRequests() diff=%s", cmp.Diff(got, want))
+	}
+}
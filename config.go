@@ -3,19 +3,360 @@ package mikku
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"regexp"
 
 	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	errEmptyGitHubAccessToken = errors.New("should be set MIKKU_GITHUB_ACCESS_TOKEN")
 	errEmptyGitHubOwner       = errors.New("should be set MIKKU_GITHUB_OWNER")
+	errInvalidMergeMethod     = errors.New("merge method should be squash, merge or rebase")
+	errEmptyRegistryHost      = errors.New("should be set MIKKU_REGISTRY_HOST with the registry credentials")
 )
 
 // Config represents config using all commands
 type Config struct {
 	GitHubAccessToken string `envconfig:"MIKKU_GITHUB_ACCESS_TOKEN" required:"true"`
 	GitHubOwner       string `envconfig:"MIKKU_GITHUB_OWNER" required:"true"`
+	// ConfigFile is the path of the YAML config file. It is optional.
+	ConfigFile string `envconfig:"MIKKU_CONFIG_FILE"`
+	// AuditLog is the path of the file which destructive operations are appended to. It is optional.
+	AuditLog string `envconfig:"MIKKU_AUDIT_LOG"`
+	// RegistryHost is the container registry which RegistryUsername and RegistryPassword are sent to. Ex. ghcr.io
+	RegistryHost string `envconfig:"MIKKU_REGISTRY_HOST"`
+	// RegistryUsername and RegistryPassword are the credentials of the container registry. They are optional for public images.
+	RegistryUsername string `envconfig:"MIKKU_REGISTRY_USERNAME"`
+	RegistryPassword string `envconfig:"MIKKU_REGISTRY_PASSWORD"`
+
+	Repositories map[string]*RepositoryConfig `ignored:"true"`
+}
+
+// fileConfig represents the content of the config file
+type fileConfig struct {
+	Repositories map[string]*RepositoryConfig `yaml:"repositories"`
+}
+
+// RepositoryConfig represents config for each repository
+type RepositoryConfig struct {
+	VersionFiles []*VersionFile   `yaml:"versionFiles"`
+	Changelog    *ChangelogConfig `yaml:"changelog"`
+	// DependsOn is the repositories released before the repository by `mikku release-batch`
+	DependsOn []string `yaml:"dependsOn"`
+	// Manifest is the Kubernetes manifests updated by `mikku pr`
+	Manifest *ManifestConfig `yaml:"manifest"`
+}
+
+// ManifestConfig represents Kubernetes manifests which deploy the image of the repository
+type ManifestConfig struct {
+	// Repository is the name of the manifest repository owned by MIKKU_GITHUB_OWNER
+	Repository string `yaml:"repository"`
+	// Image is the container image name without tag. Ex. ghcr.io/p1ass/mikku
+	Image string `yaml:"image"`
+	// Kustomize is the overlay directories whose kustomization.yaml has the image in `images:`
+	Kustomize []string `yaml:"kustomize"`
+	// Helm is the Helm chart files which have the image tag
+	Helm *HelmConfig `yaml:"helm"`
+	// PullRequest is the settings of the pull requests updating the manifests
+	PullRequest *PullRequestConfig `yaml:"pullRequest"`
+	// Environments is the manifests of each environment used by `mikku promote`. Ex. dev, staging and prod
+	Environments map[string]*EnvironmentConfig `yaml:"environments"`
+	// Git updates the manifests in a local git working copy instead of GitHub API. It is optional.
+	Git *GitConfig `yaml:"git"`
+	// Validation is the settings of validating the edited manifests before committing them
+	Validation *ValidationConfig `yaml:"validation"`
+}
+
+// ValidationConfig represents the validation of the resources rendered from the edited kustomize overlays
+// If it is not set, the edited files are only parsed as YAML.
+type ValidationConfig struct {
+	// KubernetesVersion is the version of the cluster whose schemas the resources are validated against. Ex. 1.27.3
+	// If it is empty, the resources are not validated against schemas.
+	KubernetesVersion string `yaml:"kubernetesVersion"`
+	// SchemaDir is the local cache of the JSON schemas converted from Kubernetes OpenAPI (default: <user cache dir>/mikku/schemas)
+	SchemaDir string `yaml:"schemaDir"`
+}
+
+// override returns the validation whose fields are replaced by the ones set in other
+func (vc *ValidationConfig) override(other *ValidationConfig) *ValidationConfig {
+	if other == nil {
+		return vc
+	}
+	if vc == nil {
+		vc = &ValidationConfig{}
+	}
+	overridden := *vc
+	if other.KubernetesVersion != "" {
+		overridden.KubernetesVersion = other.KubernetesVersion
+	}
+	if other.SchemaDir != "" {
+		overridden.SchemaDir = other.SchemaDir
+	}
+	return &overridden
+}
+
+func (vc *ValidationConfig) validate() error {
+	if vc == nil || vc.KubernetesVersion == "" {
+		return nil
+	}
+	if !kubernetesVersionReg.MatchString(vc.KubernetesVersion) {
+		return fmt.Errorf("validation: invalid kubernetesVersion: %s", vc.KubernetesVersion)
+	}
+	return nil
+}
+
+// GitConfig represents the local git working copy of the manifest repository
+// The branch is pushed to the remote. If repository is also set, the pull request is opened on GitHub.
+type GitConfig struct {
+	// URL is the remote cloned into a temporary directory. Ex. git@gitlab.com:p1ass/manifests.git
+	URL string `yaml:"url"`
+	// Path is an existing checkout used instead of cloning URL. Its working tree is left as it is.
+	Path string `yaml:"path"`
+	// Remote is the remote of the checkout which is fetched and pushed to (default: origin)
+	Remote string `yaml:"remote"`
+	// AuthorName and AuthorEmail are the author and the committer of the commits. If they are empty, the git config is used.
+	AuthorName  string `yaml:"authorName"`
+	AuthorEmail string `yaml:"authorEmail"`
+	// SigningKey is the key signing the commits. If it is empty, the commits are signed as the git config says.
+	SigningKey string `yaml:"signingKey"`
+}
+
+func (gc *GitConfig) validate() error {
+	if gc == nil {
+		return nil
+	}
+	if (gc.URL == "") == (gc.Path == "") {
+		return errors.New("git: either url or path should be set")
+	}
+	return nil
+}
+
+// EnvironmentConfig represents the manifests of an environment
+type EnvironmentConfig struct {
+	Kustomize []string    `yaml:"kustomize"`
+	Helm      *HelmConfig `yaml:"helm"`
+	// PullRequest overrides the settings of the manifest. The fields which are not set are inherited.
+	PullRequest *PullRequestConfig `yaml:"pullRequest"`
+	// Validation overrides the validation of the manifest such as the Kubernetes version of the cluster
+	Validation *ValidationConfig `yaml:"validation"`
+}
+
+// PullRequestConfig represents settings of a pull request opened by mikku
+type PullRequestConfig struct {
+	Reviewers []string `yaml:"reviewers"`
+	// TeamReviewers is the slugs of the teams in the owner organization
+	TeamReviewers []string `yaml:"teamReviewers"`
+	Labels        []string `yaml:"labels"`
+	Assignees     []string `yaml:"assignees"`
+	// AutoMerge is the merge method of auto-merge: squash, merge or rebase. If it is empty or disabled, auto-merge is not enabled.
+	AutoMerge string `yaml:"autoMerge"`
+}
+
+// autoMergeDisabled turns off auto-merge enabled by the top-level settings
+const autoMergeDisabled = "disabled"
+
+func (pc *PullRequestConfig) validate() error {
+	if pc == nil {
+		return nil
+	}
+	switch pc.AutoMerge {
+	case "", "squash", "merge", "rebase", autoMergeDisabled:
+		return nil
+	default:
+		return fmt.Errorf("%s: %w", pc.AutoMerge, errInvalidMergeMethod)
+	}
+}
+
+// merge returns the settings overridden by other
+// The lists are concatenated without duplicates and AutoMerge is overridden only if it is set in other.
+func (pc *PullRequestConfig) merge(other *PullRequestConfig) *PullRequestConfig {
+	if pc == nil {
+		pc = &PullRequestConfig{}
+	}
+	if other == nil {
+		other = &PullRequestConfig{}
+	}
+	merged := &PullRequestConfig{
+		Reviewers:     appendUnique(pc.Reviewers, other.Reviewers),
+		TeamReviewers: appendUnique(pc.TeamReviewers, other.TeamReviewers),
+		Labels:        appendUnique(pc.Labels, other.Labels),
+		Assignees:     appendUnique(pc.Assignees, other.Assignees),
+		AutoMerge:     pc.AutoMerge,
+	}
+	if other.AutoMerge != "" {
+		merged.AutoMerge = other.AutoMerge
+	}
+	return merged
+}
+
+// override returns the settings whose fields are replaced by the ones set in other
+// It is used for environments so that an environment can drop reviewers or auto-merge of the top-level settings.
+func (pc *PullRequestConfig) override(other *PullRequestConfig) *PullRequestConfig {
+	if pc == nil {
+		pc = &PullRequestConfig{}
+	}
+	if other == nil {
+		other = &PullRequestConfig{}
+	}
+	overridden := *pc
+	if other.Reviewers != nil {
+		overridden.Reviewers = other.Reviewers
+	}
+	if other.TeamReviewers != nil {
+		overridden.TeamReviewers = other.TeamReviewers
+	}
+	if other.Labels != nil {
+		overridden.Labels = other.Labels
+	}
+	if other.Assignees != nil {
+		overridden.Assignees = other.Assignees
+	}
+	if other.AutoMerge != "" {
+		overridden.AutoMerge = other.AutoMerge
+	}
+	return &overridden
+}
+
+// autoMergeMethod returns the merge method of auto-merge. It is empty if auto-merge is not enabled.
+func (pc *PullRequestConfig) autoMergeMethod() string {
+	if pc == nil || pc.AutoMerge == autoMergeDisabled {
+		return ""
+	}
+	return pc.AutoMerge
+}
+
+func appendUnique(a, b []string) []string {
+	var merged []string
+	seen := map[string]bool{}
+	for _, s := range append(append([]string{}, a...), b...) {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// HelmConfig represents the image tag in Helm charts
+type HelmConfig struct {
+	// Values is the targets formatted as `file:yamlpath`. Ex. `charts/app/values.yaml:api.image.tag`
+	Values []string `yaml:"values"`
+	// Chart is the path of Chart.yaml whose appVersion is bumped. It is optional.
+	Chart string `yaml:"chart"`
+}
+
+func (mc *ManifestConfig) validate() error {
+	if (mc.Repository == "" && mc.Git == nil) || mc.Image == "" {
+		return errors.New("manifest: repository or git, and image should be set")
+	}
+	if err := mc.Git.validate(); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	if err := mc.Validation.validate(); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	if err := mc.Helm.validate(); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	if err := mc.PullRequest.validate(); err != nil {
+		return fmt.Errorf("manifest: %w", err)
+	}
+	for name, env := range mc.Environments {
+		if env == nil || (len(env.Kustomize) == 0 && (env.Helm == nil || len(env.Helm.Values) == 0)) {
+			return fmt.Errorf("manifest: environment %s: kustomize or helm values should be set", name)
+		}
+		if err := env.Helm.validate(); err != nil {
+			return fmt.Errorf("manifest: environment %s: %w", name, err)
+		}
+		if err := env.PullRequest.validate(); err != nil {
+			return fmt.Errorf("manifest: environment %s: %w", name, err)
+		}
+		if err := env.Validation.validate(); err != nil {
+			return fmt.Errorf("manifest: environment %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// environment returns the manifests of the environment
+func (mc *ManifestConfig) environment(name string) (*ManifestConfig, error) {
+	env, ok := mc.Environments[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, errEnvironmentNotFound)
+	}
+	return &ManifestConfig{
+		Repository:  mc.Repository,
+		Image:       mc.Image,
+		Kustomize:   env.Kustomize,
+		Helm:        env.Helm,
+		PullRequest: mc.PullRequest.override(env.PullRequest),
+		Git:         mc.Git,
+		Validation:  mc.Validation.override(env.Validation),
+	}, nil
+}
+
+func (hc *HelmConfig) validate() error {
+	if hc == nil {
+		return nil
+	}
+	for _, v := range hc.Values {
+		if _, _, err := parseHelmValuesTarget(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChangelogConfig represents rules of pull requests listed in the release body
+type ChangelogConfig struct {
+	// Exclude is the rule of pull requests which are not listed
+	Exclude *PullRequestRule `yaml:"exclude"`
+	// DependencyUpdates is the rule of pull requests which are folded into a single line
+	DependencyUpdates *PullRequestRule `yaml:"dependencyUpdates"`
+}
+
+func (cc *ChangelogConfig) compile() error {
+	for _, rule := range []*PullRequestRule{cc.Exclude, cc.DependencyUpdates} {
+		if rule == nil {
+			continue
+		}
+		if err := rule.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VersionFile represents a file which embeds the version
+// Either Key or Pattern must be set.
+type VersionFile struct {
+	Path string `yaml:"path"`
+	// Key is the dot-separated path of the version in a YAML or JSON file. Ex. `version`
+	Key string `yaml:"key"`
+	// Pattern is the regular expression whose first capture group is the version. Ex. `Version = "(.+)"`
+	Pattern string `yaml:"pattern"`
+}
+
+func (vf *VersionFile) validate() error {
+	if vf.Path == "" {
+		return errors.New("path of version file should be set")
+	}
+	if (vf.Key == "") == (vf.Pattern == "") {
+		return fmt.Errorf("%s: either key or pattern should be set", vf.Path)
+	}
+	if vf.Pattern != "" {
+		reg, err := regexp.Compile(vf.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", vf.Path, err)
+		}
+		if reg.NumSubexp() == 0 {
+			return fmt.Errorf("%s: pattern should have a capture group", vf.Path)
+		}
+	}
+	return nil
 }
 
 func (cfg *Config) validate() error {
@@ -35,5 +376,60 @@ func readConfig() (*Config, error) {
 	if err := envconfig.Process("", cfg); err != nil {
 		return nil, fmt.Errorf("failed to read environment variables: %w", err)
 	}
+	// The credentials must not be sent to the registries of other images
+	if cfg.RegistryUsername != "" && cfg.RegistryHost == "" {
+		return nil, errEmptyRegistryHost
+	}
+
+	if cfg.ConfigFile != "" {
+		if err := cfg.readFile(); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
 	return cfg, nil
 }
+
+func (cfg *Config) readFile() error {
+	b, err := ioutil.ReadFile(cfg.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", cfg.ConfigFile, err)
+	}
+
+	fc := &fileConfig{}
+	if err := yaml.Unmarshal(b, fc); err != nil {
+		return fmt.Errorf("parse %s: %w", cfg.ConfigFile, err)
+	}
+
+	for name, repoCfg := range fc.Repositories {
+		if repoCfg == nil {
+			continue
+		}
+		for _, vf := range repoCfg.VersionFiles {
+			if err := vf.validate(); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if repoCfg.Changelog != nil {
+			if err := repoCfg.Changelog.compile(); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if repoCfg.Manifest != nil {
+			if err := repoCfg.Manifest.validate(); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	cfg.Repositories = fc.Repositories
+	return nil
+}
+
+// repository returns the config of the repository
+// If the repository is not configured, it returns an empty config.
+func (cfg *Config) repository(name string) *RepositoryConfig {
+	if repoCfg, ok := cfg.Repositories[name]; ok && repoCfg != nil {
+		return repoCfg
+	}
+	return &RepositoryConfig{}
+}
@@ -2,10 +2,13 @@ package mikku
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
 )
 
 func TestConfig_validate(t *testing.T) {
@@ -43,7 +46,7 @@ func TestConfig_validate(t *testing.T) {
 				GitHubOwner:       tt.GitHubOwner,
 			}
 			err := cfg.validate()
-			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.As(err, &tt.wantErr)) {
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
 				t.Errorf("Config.validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -87,6 +90,21 @@ func TestReadConfig(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "registry credentials without host",
+			setEnv: func() func() {
+				_ = os.Setenv("MIKKU_GITHUB_ACCESS_TOKEN", "MIKKU_GITHUB_ACCESS_TOKEN")
+				_ = os.Setenv("MIKKU_GITHUB_OWNER", "MIKKU_GITHUB_OWNER")
+				_ = os.Setenv("MIKKU_REGISTRY_USERNAME", "MIKKU_REGISTRY_USERNAME")
+				return func() {
+					_ = os.Unsetenv("MIKKU_GITHUB_ACCESS_TOKEN")
+					_ = os.Unsetenv("MIKKU_GITHUB_OWNER")
+					_ = os.Unsetenv("MIKKU_REGISTRY_USERNAME")
+				}
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "All env config be set",
 			setEnv: func() func() {
@@ -119,3 +137,474 @@ func TestReadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_readFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]*RepositoryConfig
+		wantErr bool
+	}{
+		{
+			name: "version files",
+			content: `
+repositories:
+  sample-repository:
+    versionFiles:
+      - path: package.json
+        key: version
+      - path: version.go
+        pattern: 'Version = "(.+)"'
+`,
+			want: map[string]*RepositoryConfig{
+				"sample-repository": {
+					VersionFiles: []*VersionFile{
+						{Path: "package.json", Key: "version"},
+						{Path: "version.go", Pattern: `Version = "(.+)"`},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "both key and pattern are set",
+			content: `
+repositories:
+  sample-repository:
+    versionFiles:
+      - path: package.json
+        key: version
+        pattern: '"version": "(.+)"'
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "pattern without capture group",
+			content: `
+repositories:
+  sample-repository:
+    versionFiles:
+      - path: version.go
+        pattern: 'Version = ".+"'
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "helm manifest",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      helm:
+        values:
+          - charts/app/values.yaml:image.tag
+        chart: charts/app/Chart.yaml
+`,
+			want: map[string]*RepositoryConfig{
+				"sample-repository": {
+					Manifest: &ManifestConfig{
+						Repository: "sample-manifests",
+						Image:      "ghcr.io/p1ass/sample-repository",
+						Helm: &HelmConfig{
+							Values: []string{"charts/app/values.yaml:image.tag"},
+							Chart:  "charts/app/Chart.yaml",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "environments",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      environments:
+        staging:
+          kustomize: [overlays/staging]
+        prod:
+          helm:
+            values: [charts/prod/values.yaml:image.tag]
+`,
+			want: map[string]*RepositoryConfig{
+				"sample-repository": {
+					Manifest: &ManifestConfig{
+						Repository: "sample-manifests",
+						Image:      "ghcr.io/p1ass/sample-repository",
+						Environments: map[string]*EnvironmentConfig{
+							"staging": {Kustomize: []string{"overlays/staging"}},
+							"prod":    {Helm: &HelmConfig{Values: []string{"charts/prod/values.yaml:image.tag"}}},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid auto-merge method",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      environments:
+        dev:
+          kustomize: [overlays/dev]
+          pullRequest:
+            autoMerge: fast-forward
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "environment without manifests",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      environments:
+        prod: {}
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "local git working copy without repository",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      image: ghcr.io/p1ass/sample-repository
+      kustomize: [overlays/prod]
+      git:
+        url: git@gitlab.com:p1ass/sample-manifests.git
+        authorName: mikku
+        authorEmail: mikku@example.com
+        signingKey: ABCDEF0123456789
+`,
+			want: map[string]*RepositoryConfig{
+				"sample-repository": {
+					Manifest: &ManifestConfig{
+						Image:     "ghcr.io/p1ass/sample-repository",
+						Kustomize: []string{"overlays/prod"},
+						Git: &GitConfig{
+							URL:         "git@gitlab.com:p1ass/sample-manifests.git",
+							AuthorName:  "mikku",
+							AuthorEmail: "mikku@example.com",
+							SigningKey:  "ABCDEF0123456789",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "both git url and path are set",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      image: ghcr.io/p1ass/sample-repository
+      git:
+        url: git@gitlab.com:p1ass/sample-manifests.git
+        path: /src/sample-manifests
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "validation",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      validation:
+        kubernetesVersion: 1.27.3
+        schemaDir: /var/cache/schemas
+`,
+			want: map[string]*RepositoryConfig{
+				"sample-repository": {
+					Manifest: &ManifestConfig{
+						Repository: "sample-manifests",
+						Image:      "ghcr.io/p1ass/sample-repository",
+						Validation: &ValidationConfig{KubernetesVersion: "1.27.3", SchemaDir: "/var/cache/schemas"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid kubernetes version",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      validation:
+        kubernetesVersion: latest
+`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "helm values without key",
+			content: `
+repositories:
+  sample-repository:
+    manifest:
+      repository: sample-manifests
+      image: ghcr.io/p1ass/sample-repository
+      helm:
+        values:
+          - charts/app/values.yaml
+`,
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "mikku.yml")
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &Config{ConfigFile: path}
+			err := cfg.readFile()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.readFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(cfg.Repositories, tt.want) {
+				t.Errorf("Config.readFile() diff=%s", cmp.Diff(cfg.Repositories, tt.want))
+			}
+		})
+	}
+}
+
+func TestPullRequestConfig_merge(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		base  *PullRequestConfig
+		other *PullRequestConfig
+		want  *PullRequestConfig
+	}{
+		{
+			name: "concatenate lists and override auto-merge",
+			base: &PullRequestConfig{
+				Reviewers: []string{"p1ass"},
+				Labels:    []string{"deploy"},
+				AutoMerge: "merge",
+			},
+			other: &PullRequestConfig{
+				Reviewers:     []string{"alice", "p1ass"},
+				TeamReviewers: []string{"sre"},
+				AutoMerge:     "squash",
+			},
+			want: &PullRequestConfig{
+				Reviewers:     []string{"p1ass", "alice"},
+				TeamReviewers: []string{"sre"},
+				Labels:        []string{"deploy"},
+				AutoMerge:     "squash",
+			},
+		},
+		{
+			name:  "keep auto-merge",
+			base:  &PullRequestConfig{AutoMerge: "rebase"},
+			other: &PullRequestConfig{Assignees: []string{"p1ass"}},
+			want:  &PullRequestConfig{Assignees: []string{"p1ass"}, AutoMerge: "rebase"},
+		},
+		{
+			name:  "nil",
+			base:  nil,
+			other: nil,
+			want:  &PullRequestConfig{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.base.merge(tt.other)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("PullRequestConfig.merge() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestPullRequestConfig_override(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		base  *PullRequestConfig
+		other *PullRequestConfig
+		want  *PullRequestConfig
+	}{
+		{
+			name: "replace lists and auto-merge which are set",
+			base: &PullRequestConfig{
+				Reviewers: []string{"p1ass"},
+				Labels:    []string{"deploy"},
+				AutoMerge: "squash",
+			},
+			other: &PullRequestConfig{
+				Reviewers:     []string{"alice"},
+				TeamReviewers: []string{"sre"},
+				AutoMerge:     autoMergeDisabled,
+			},
+			want: &PullRequestConfig{
+				Reviewers:     []string{"alice"},
+				TeamReviewers: []string{"sre"},
+				Labels:        []string{"deploy"},
+				AutoMerge:     autoMergeDisabled,
+			},
+		},
+		{
+			name:  "empty list drops the base one",
+			base:  &PullRequestConfig{Reviewers: []string{"p1ass"}, AutoMerge: "rebase"},
+			other: &PullRequestConfig{Reviewers: []string{}},
+			want:  &PullRequestConfig{Reviewers: []string{}, AutoMerge: "rebase"},
+		},
+		{
+			name:  "nil",
+			base:  nil,
+			other: nil,
+			want:  &PullRequestConfig{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.base.override(tt.other)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("PullRequestConfig.override() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestManifestConfig_environment_pullRequest(t *testing.T) {
+	t.Parallel()
+
+	mc := &ManifestConfig{}
+	if err := yaml.Unmarshal([]byte(`
+repository: manifests
+image: ghcr.io/p1ass/mikku
+pullRequest:
+  reviewers: [p1ass]
+  labels: [deploy]
+  autoMerge: squash
+environments:
+  dev:
+    kustomize: [overlays/dev]
+  prod:
+    kustomize: [overlays/prod]
+    pullRequest:
+      reviewers: []
+      teamReviewers: [sre]
+      autoMerge: disabled
+`), mc); err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	tests := []struct {
+		env  string
+		want *PullRequestConfig
+		// wantAutoMerge is the merge method of auto-merge enabled on the pull request
+		wantAutoMerge string
+	}{
+		{
+			env:           "dev",
+			want:          &PullRequestConfig{Reviewers: []string{"p1ass"}, Labels: []string{"deploy"}, AutoMerge: "squash"},
+			wantAutoMerge: "squash",
+		},
+		{
+			env:           "prod",
+			want:          &PullRequestConfig{Reviewers: []string{}, TeamReviewers: []string{"sre"}, Labels: []string{"deploy"}, AutoMerge: autoMergeDisabled},
+			wantAutoMerge: "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.env, func(t *testing.T) {
+			t.Parallel()
+			env, err := mc.environment(tt.env)
+			if err != nil {
+				t.Fatalf("environment() error = %v", err)
+			}
+			if !cmp.Equal(env.PullRequest, tt.want) {
+				t.Errorf("environment() diff=%s", cmp.Diff(env.PullRequest, tt.want))
+			}
+			if got := env.PullRequest.autoMergeMethod(); got != tt.wantAutoMerge {
+				t.Errorf("autoMergeMethod() = %s, want %s", got, tt.wantAutoMerge)
+			}
+		})
+	}
+}
+
+func TestManifestConfig_environment_validation(t *testing.T) {
+	t.Parallel()
+
+	mc := &ManifestConfig{
+		Repository: "manifests",
+		Image:      "ghcr.io/p1ass/mikku",
+		Validation: &ValidationConfig{KubernetesVersion: "1.27.3", SchemaDir: "/schemas"},
+		Environments: map[string]*EnvironmentConfig{
+			"dev":  {Kustomize: []string{"overlays/dev"}},
+			"prod": {Kustomize: []string{"overlays/prod"}, Validation: &ValidationConfig{KubernetesVersion: "1.26.6"}},
+		},
+	}
+
+	tests := []struct {
+		env  string
+		want *ValidationConfig
+	}{
+		{env: "dev", want: &ValidationConfig{KubernetesVersion: "1.27.3", SchemaDir: "/schemas"}},
+		{env: "prod", want: &ValidationConfig{KubernetesVersion: "1.26.6", SchemaDir: "/schemas"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.env, func(t *testing.T) {
+			t.Parallel()
+			env, err := mc.environment(tt.env)
+			if err != nil {
+				t.Fatalf("environment() error = %v", err)
+			}
+			if !cmp.Equal(env.Validation, tt.want) {
+				t.Errorf("environment() diff=%s", cmp.Diff(env.Validation, tt.want))
+			}
+		})
+	}
+
+	invalid := &ManifestConfig{
+		Repository:   "manifests",
+		Image:        "ghcr.io/p1ass/mikku",
+		Environments: map[string]*EnvironmentConfig{"prod": {Kustomize: []string{"overlays/prod"}, Validation: &ValidationConfig{KubernetesVersion: "latest"}}},
+	}
+	if err := invalid.validate(); err == nil {
+		t.Error("validate() error = nil, want invalid kubernetesVersion of the environment")
+	}
+}
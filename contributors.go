@@ -0,0 +1,85 @@
+package mikku
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// contributor represents a user who authored pull requests in the release
+type contributor struct {
+	Login string
+	// FirstTime is true if the user had no merged pull request before the previous release
+	FirstTime bool
+	// FirstPullRequest is the earliest merged pull request of the user in the release
+	FirstPullRequest *github.PullRequest
+}
+
+// collectContributors returns the de-duplicated contributors sorted by login
+// If isFirstRelease is true, all contributors are first-time contributors.
+func collectContributors(ctx context.Context, svc *githubClient, repo string, prs []*github.PullRequest, after time.Time, isFirstRelease bool) ([]*contributor, error) {
+	contributors := extractContributors(prs)
+	if isFirstRelease {
+		for _, c := range contributors {
+			c.FirstTime = true
+		}
+		return contributors, nil
+	}
+
+	logins := make([]string, 0, len(contributors))
+	for _, c := range contributors {
+		logins = append(logins, c.Login)
+	}
+	contributed, err := svc.searchContributionsBefore(ctx, repo, logins, after)
+	if err != nil {
+		return nil, fmt.Errorf("check contributions: %w", err)
+	}
+	for _, c := range contributors {
+		c.FirstTime = !contributed[c.Login]
+	}
+	return contributors, nil
+}
+
+// extractContributors returns the de-duplicated authors of the pull requests sorted by login
+func extractContributors(prs []*github.PullRequest) []*contributor {
+	byLogin := map[string]*contributor{}
+	for _, pr := range prs {
+		login := pr.GetUser().GetLogin()
+		if login == "" {
+			continue
+		}
+
+		c, ok := byLogin[login]
+		if !ok {
+			byLogin[login] = &contributor{Login: login, FirstPullRequest: pr}
+			continue
+		}
+		if pr.GetMergedAt().Before(c.FirstPullRequest.GetMergedAt()) {
+			c.FirstPullRequest = pr
+		}
+	}
+
+	contributors := make([]*contributor, 0, len(byLogin))
+	for _, c := range byLogin {
+		contributors = append(contributors, c)
+	}
+	sort.Slice(contributors, func(i, j int) bool {
+		return strings.ToLower(contributors[i].Login) < strings.ToLower(contributors[j].Login)
+	})
+	return contributors
+}
+
+// newContributors filters first-time contributors
+func newContributors(contributors []*contributor) []*contributor {
+	var newcomers []*contributor
+	for _, c := range contributors {
+		if c.FirstTime {
+			newcomers = append(newcomers, c)
+		}
+	}
+	return newcomers
+}
@@ -0,0 +1,86 @@
+package mikku
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_collectContributors(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := &github.PullRequest{
+		Number:   github.Int(1),
+		User:     &github.User{Login: github.String("test-owner")},
+		MergedAt: timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+	}
+	second := &github.PullRequest{
+		Number:   github.Int(2),
+		User:     &github.User{Login: github.String("Bob")},
+		MergedAt: timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+	}
+	third := &github.PullRequest{
+		Number:   github.Int(3),
+		User:     &github.User{Login: github.String("test-owner")},
+		MergedAt: timeToPointer(time.Date(2019, 1, 4, 0, 0, 0, 0, time.UTC)),
+	}
+	prs := []*github.PullRequest{third, second, first}
+
+	tests := []struct {
+		name           string
+		isFirstRelease bool
+		injector       func(cli *MockgitHubGraphQLClient)
+		want           []*contributor
+	}{
+		{
+			name:           "check contributions before the previous release",
+			isFirstRelease: false,
+			injector: func(cli *MockgitHubGraphQLClient) {
+				cli.EXPECT().Query(gomock.Any(), contributionsQuery(2), map[string]interface{}{
+					"q0": "repo:test-owner/test-repo is:pr is:merged author:Bob merged:<2019-01-01T00:00:00Z",
+					"q1": "repo:test-owner/test-repo is:pr is:merged author:test-owner merged:<2019-01-01T00:00:00Z",
+				}, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+					return json.Unmarshal([]byte(`{"s0":{"issueCount":0},"s1":{"issueCount":5}}`), result)
+				})
+			},
+			want: []*contributor{
+				{Login: "Bob", FirstTime: true, FirstPullRequest: second},
+				{Login: "test-owner", FirstTime: false, FirstPullRequest: first},
+			},
+		},
+		{
+			name:           "first release",
+			isFirstRelease: true,
+			injector:       func(cli *MockgitHubGraphQLClient) {},
+			want: []*contributor{
+				{Login: "Bob", FirstTime: true, FirstPullRequest: second},
+				{Login: "test-owner", FirstTime: true, FirstPullRequest: first},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			cli := NewMockgitHubGraphQLClient(ctrl)
+			tt.injector(cli)
+
+			s := newGitHubClient("test-owner", gitHubClients{graphql: cli})
+
+			got, err := collectContributors(context.Background(), s, "test-repo", prs, after, tt.isFirstRelease)
+			if err != nil {
+				t.Errorf("collectContributors() error = %v", err)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("collectContributors() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	errDependencyCycle = errors.New("dependency cycle")
+)
+
+// dependencyGraph maps the repository to the repositories it depends on
+// Only repositories released in the same batch are included.
+type dependencyGraph map[string][]string
+
+// buildDependencyGraph builds the graph from dependsOn in the config and, if modules are given, go.mod requirements
+func buildDependencyGraph(cfg *Config, targets []*ReleaseTarget, modules map[string]*goModule) dependencyGraph {
+	inBatch := make(map[string]bool, len(targets))
+	repoByModule := make(map[string]string, len(modules))
+	for _, target := range targets {
+		inBatch[target.Repository] = true
+		if m, ok := modules[target.Repository]; ok {
+			repoByModule[m.path] = target.Repository
+		}
+	}
+
+	graph := make(dependencyGraph, len(targets))
+	for _, target := range targets {
+		deps := make(map[string]bool)
+		for _, dep := range cfg.repository(target.Repository).DependsOn {
+			if inBatch[dep] && dep != target.Repository {
+				deps[dep] = true
+			}
+		}
+		if m, ok := modules[target.Repository]; ok {
+			for path := range m.requires {
+				if dep, ok := repoByModule[path]; ok && dep != target.Repository {
+					deps[dep] = true
+				}
+			}
+		}
+
+		graph[target.Repository] = make([]string, 0, len(deps))
+		for dep := range deps {
+			graph[target.Repository] = append(graph[target.Repository], dep)
+		}
+		sort.Strings(graph[target.Repository])
+	}
+	return graph
+}
+
+// sortTargets groups the targets into stages in topological order
+// Targets in a stage depend only on targets in the former stages, so they can be released concurrently.
+func sortTargets(targets []*ReleaseTarget, graph dependencyGraph) ([][]*ReleaseTarget, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(targets))
+	depth := make(map[string]int, len(targets))
+	var path []string
+
+	var visit func(repo string) error
+	visit = func(repo string) error {
+		switch state[repo] {
+		case visited:
+			return nil
+		case visiting:
+			// Report the cycle from the first appearance of the repository
+			for i, r := range path {
+				if r == repo {
+					cycle := append(append([]string{}, path[i:]...), repo)
+					return fmt.Errorf("%s: %w", strings.Join(cycle, " -> "), errDependencyCycle)
+				}
+			}
+		}
+
+		state[repo] = visiting
+		path = append(path, repo)
+		d := 0
+		for _, dep := range graph[repo] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+			if depth[dep]+1 > d {
+				d = depth[dep] + 1
+			}
+		}
+		path = path[:len(path)-1]
+		state[repo] = visited
+		depth[repo] = d
+		return nil
+	}
+
+	var stages [][]*ReleaseTarget
+	for _, target := range targets {
+		if err := visit(target.Repository); err != nil {
+			return nil, err
+		}
+		d := depth[target.Repository]
+		for len(stages) <= d {
+			stages = append(stages, nil)
+		}
+		stages[d] = append(stages[d], target)
+	}
+	return stages, nil
+}
+
+// readGoModules reads go.mod in the base branch of each target
+// Repositories without go.mod are not included.
+func readGoModules(ctx context.Context, svc *githubClient, targets []*ReleaseTarget) (map[string]*goModule, error) {
+	modules := make(map[string]*goModule, len(targets))
+	for _, target := range targets {
+		content, err := svc.getFile(ctx, target.Repository, goModFile, baseBranch)
+		if err != nil {
+			if errors.Is(err, errFileNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", target.Repository, err)
+		}
+
+		m, err := parseGoMod(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", target.Repository, err)
+		}
+		modules[target.Repository] = m
+	}
+	return modules, nil
+}
+
+// dependencyBump represents a dependency released in the batch and its new tag
+type dependencyBump struct {
+	repo   string
+	module string
+	tag    string
+}
+
+// openDependencyBumpPullRequest opens a pull request which updates go.mod of the repository to the new tags
+// It returns an empty string if go.mod already requires the new tags.
+func openDependencyBumpPullRequest(ctx context.Context, svc *githubClient, repo string, m *goModule, bumps []*dependencyBump) (string, error) {
+	versions := make(map[string]string, len(bumps))
+	names := make([]string, 0, len(bumps))
+	lines := make([]string, 0, len(bumps))
+	for _, b := range bumps {
+		if current, ok := m.requires[b.module]; !ok || current == b.tag {
+			continue
+		}
+		versions[b.module] = b.tag
+		names = append(names, b.repo+"-"+b.tag)
+		lines = append(lines, fmt.Sprintf("- `%s` %s → %s", b.module, m.requires[b.module], b.tag))
+	}
+	if len(versions) == 0 {
+		return "", nil
+	}
+
+	content, err := bumpGoModRequires(m.content, versions)
+	if err != nil {
+		return "", fmt.Errorf("bump %s: %w", goModFile, err)
+	}
+
+	branch := "mikku/bump-" + strings.Join(names, "-")
+	message := fmt.Sprintf("Bump %s", strings.Join(names, ", "))
+	if err := svc.createBranch(ctx, repo, baseBranch, branch); err != nil {
+		return "", fmt.Errorf("create dependency bump branch: %w", err)
+	}
+	// The branch is left if the following steps fail, so report it to clean up
+	if _, err := svc.commitFiles(ctx, repo, branch, message, []*fileChange{{path: goModFile, content: content}}); err != nil {
+		return "", fmt.Errorf("commit %s (branch %s was created): %w", goModFile, branch, err)
+	}
+	body := "Update the dependencies released by mikku.\n\n" + strings.Join(lines, "\n") + "\n\n" +
+		"`go.sum` is not updated. Run `go mod tidy` on this branch if it is required.\n"
+	pr, err := svc.createPullRequest(ctx, repo, branch, baseBranch, message, body)
+	if err != nil {
+		return "", fmt.Errorf("create dependency bump pull request (branch %s was created): %w", branch, err)
+	}
+	return pr.GetHTMLURL(), nil
+}
@@ -0,0 +1,141 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_buildDependencyGraph(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Repositories: map[string]*RepositoryConfig{
+			"lib-http": {DependsOn: []string{"lib-core", "not-in-batch"}},
+		},
+	}
+	targets := []*ReleaseTarget{
+		{Repository: "service", Bump: "patch"},
+		{Repository: "lib-http", Bump: "minor"},
+		{Repository: "lib-core", Bump: "minor"},
+	}
+	modules := map[string]*goModule{
+		"service": {
+			path: "github.com/test-owner/service",
+			requires: map[string]string{
+				"github.com/test-owner/lib-http": "v1.0.0",
+				"github.com/test-owner/lib-core": "v1.0.0",
+				"github.com/google/go-cmp":       "v0.5.6",
+			},
+		},
+		"lib-http": {path: "github.com/test-owner/lib-http"},
+		"lib-core": {path: "github.com/test-owner/lib-core"},
+	}
+
+	tests := []struct {
+		name    string
+		modules map[string]*goModule
+		want    dependencyGraph
+	}{
+		{
+			name:    "config only",
+			modules: nil,
+			want: dependencyGraph{
+				"service":  {},
+				"lib-http": {"lib-core"},
+				"lib-core": {},
+			},
+		},
+		{
+			name:    "config and go.mod",
+			modules: modules,
+			want: dependencyGraph{
+				"service":  {"lib-core", "lib-http"},
+				"lib-http": {"lib-core"},
+				"lib-core": {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildDependencyGraph(cfg, targets, tt.modules)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("buildDependencyGraph() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_sortTargets(t *testing.T) {
+	t.Parallel()
+
+	service := &ReleaseTarget{Repository: "service", Bump: "patch"}
+	libHTTP := &ReleaseTarget{Repository: "lib-http", Bump: "minor"}
+	libCore := &ReleaseTarget{Repository: "lib-core", Bump: "minor"}
+	tool := &ReleaseTarget{Repository: "tool", Bump: "patch"}
+
+	tests := []struct {
+		name    string
+		graph   dependencyGraph
+		want    [][]*ReleaseTarget
+		wantErr string
+	}{
+		{
+			name: "topological order",
+			graph: dependencyGraph{
+				"service":  {"lib-core", "lib-http"},
+				"lib-http": {"lib-core"},
+			},
+			want:    [][]*ReleaseTarget{{libCore, tool}, {libHTTP}, {service}},
+			wantErr: "",
+		},
+		{
+			name: "cycle",
+			graph: dependencyGraph{
+				"service":  {"lib-http"},
+				"lib-http": {"lib-core"},
+				"lib-core": {"lib-http"},
+			},
+			want:    nil,
+			wantErr: "lib-http -> lib-core -> lib-http: dependency cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sortTargets([]*ReleaseTarget{service, libHTTP, libCore, tool}, tt.graph)
+			if tt.wantErr != "" {
+				if !errors.Is(err, errDependencyCycle) || err.Error() != tt.wantErr {
+					t.Errorf("sortTargets() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sortTargets() error = %v", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("sortTargets() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_openDependencyBumpPullRequest_upToDate(t *testing.T) {
+	t.Parallel()
+
+	m := &goModule{
+		path:     "github.com/test-owner/service",
+		requires: map[string]string{"github.com/test-owner/lib-core": "v1.1.0"},
+	}
+	bumps := []*dependencyBump{
+		{repo: "lib-core", module: "github.com/test-owner/lib-core", tag: "v1.1.0"},
+		{repo: "lib-http", module: "github.com/test-owner/lib-http", tag: "v1.0.0"},
+	}
+
+	// No API calls are expected, so the client is nil
+	got, err := openDependencyBumpPullRequest(context.Background(), nil, "service", m, bumps)
+	if err != nil || got != "" {
+		t.Errorf("openDependencyBumpPullRequest() = %s, %v, want no pull request", got, err)
+	}
+}
@@ -0,0 +1,85 @@
+package mikku
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// PullRequestRule matches pull requests by labels, author logins or title patterns
+// A pull request matches the rule if any of the conditions is satisfied.
+type PullRequestRule struct {
+	Labels []string `yaml:"labels"`
+	// Authors are logins of pull request authors. Ex. `dependabot[bot]`
+	Authors []string `yaml:"authors"`
+	// Titles are regular expressions of pull request titles
+	Titles []string `yaml:"titles"`
+
+	titleRegs []*regexp.Regexp
+}
+
+func (r *PullRequestRule) compile() error {
+	r.titleRegs = make([]*regexp.Regexp, 0, len(r.Titles))
+	for _, title := range r.Titles {
+		reg, err := regexp.Compile(title)
+		if err != nil {
+			return fmt.Errorf("invalid title pattern %s: %w", title, err)
+		}
+		r.titleRegs = append(r.titleRegs, reg)
+	}
+	return nil
+}
+
+func (r *PullRequestRule) match(pr *github.PullRequest) bool {
+	if r == nil {
+		return false
+	}
+
+	for _, author := range r.Authors {
+		if strings.EqualFold(pr.GetUser().GetLogin(), author) {
+			return true
+		}
+	}
+
+	for _, label := range r.Labels {
+		for _, l := range pr.Labels {
+			if strings.EqualFold(l.GetName(), label) {
+				return true
+			}
+		}
+	}
+
+	for _, reg := range r.titleRegs {
+		if reg.MatchString(pr.GetTitle()) {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionPRs splits the pull requests into ones which match the rule and the others
+func partitionPRs(prs []*github.PullRequest, rule *PullRequestRule) ([]*github.PullRequest, []*github.PullRequest) {
+	var matched, others []*github.PullRequest
+	for _, pr := range prs {
+		if rule.match(pr) {
+			matched = append(matched, pr)
+		} else {
+			others = append(others, pr)
+		}
+	}
+	return matched, others
+}
+
+// filterPRs drops excluded pull requests and folds dependency updates
+// It returns the pull requests listed in the changelog and the folded dependency updates.
+func filterPRs(prs []*github.PullRequest, cfg *ChangelogConfig) ([]*github.PullRequest, []*github.PullRequest) {
+	if cfg == nil {
+		return prs, nil
+	}
+
+	_, prs = partitionPRs(prs, cfg.Exclude)
+	dependencyUpdates, prs := partitionPRs(prs, cfg.DependencyUpdates)
+	return prs, dependencyUpdates
+}
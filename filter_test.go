@@ -0,0 +1,97 @@
+package mikku
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_filterPRs(t *testing.T) {
+	t.Parallel()
+
+	feature := &github.PullRequest{
+		Number: github.Int(1),
+		Title:  github.String("Add new feature"),
+		User:   &github.User{Login: github.String("test-owner")},
+	}
+	skipped := &github.PullRequest{
+		Number: github.Int(2),
+		Title:  github.String("Fix typo"),
+		User:   &github.User{Login: github.String("test-owner")},
+		Labels: []*github.Label{{Name: github.String("Skip-Changelog")}},
+	}
+	chore := &github.PullRequest{
+		Number: github.Int(3),
+		Title:  github.String("chore: update CI"),
+		User:   &github.User{Login: github.String("test-owner")},
+	}
+	dependabot := &github.PullRequest{
+		Number: github.Int(4),
+		Title:  github.String("Bump github.com/google/go-cmp from v0.5.5 to v0.5.6"),
+		User:   &github.User{Login: github.String("dependabot[bot]")},
+	}
+	renovate := &github.PullRequest{
+		Number: github.Int(5),
+		Title:  github.String("Update module github.com/urfave/cli/v2 to v2.3.1"),
+		User:   &github.User{Login: github.String("renovate[bot]")},
+	}
+	prs := []*github.PullRequest{feature, skipped, chore, dependabot, renovate}
+
+	tests := []struct {
+		name                  string
+		cfg                   *ChangelogConfig
+		want                  []*github.PullRequest
+		wantDependencyUpdates []*github.PullRequest
+	}{
+		{
+			name:                  "no config",
+			cfg:                   nil,
+			want:                  prs,
+			wantDependencyUpdates: nil,
+		},
+		{
+			name: "exclude by label, author and title",
+			cfg: &ChangelogConfig{
+				Exclude: &PullRequestRule{
+					Labels:  []string{"skip-changelog"},
+					Authors: []string{"dependabot[bot]", "renovate[bot]"},
+					Titles:  []string{`^chore:`},
+				},
+			},
+			want:                  []*github.PullRequest{feature},
+			wantDependencyUpdates: nil,
+		},
+		{
+			name: "fold dependency updates",
+			cfg: &ChangelogConfig{
+				Exclude: &PullRequestRule{
+					Labels: []string{"skip-changelog"},
+				},
+				DependencyUpdates: &PullRequestRule{
+					Authors: []string{"dependabot[bot]"},
+					Titles:  []string{`^Update module `},
+				},
+			},
+			want:                  []*github.PullRequest{feature, chore},
+			wantDependencyUpdates: []*github.PullRequest{dependabot, renovate},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cfg != nil {
+				if err := tt.cfg.compile(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, gotDependencyUpdates := filterPRs(prs, tt.cfg)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("filterPRs() diff=%s", cmp.Diff(got, tt.want))
+			}
+			if !cmp.Equal(gotDependencyUpdates, tt.wantDependencyUpdates) {
+				t.Errorf("filterPRs() dependency updates diff=%s", cmp.Diff(gotDependencyUpdates, tt.wantDependencyUpdates))
+			}
+		})
+	}
+}
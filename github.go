@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v32/github"
@@ -14,11 +16,19 @@ import (
 const (
 	baseBranch  = "master"
 	listPerPage = 10
+	// searchIndexLag is how long the search index may take to reflect a merge
+	searchIndexLag = 10 * time.Minute
 )
 
 var (
 	// errReleaseNotFound represents error that the release does not found
 	errReleaseNotFound = errors.New("release not found")
+	// errFileNotFound represents error that the file does not found in the repository
+	errFileNotFound = errors.New("file not found")
+	// errRefNotFound represents error that the tag, branch or commit does not found in the repository
+	errRefNotFound = errors.New("ref not found")
+	// errTagNotFound represents error that the tag does not found in the repository
+	errTagNotFound = errors.New("tag not found")
 )
 
 //go:generate mockgen -source=$GOFILE -destination=mock_$GOFILE -package=$GOPACKAGE
@@ -27,6 +37,15 @@ var (
 type gitHubRepositoriesClient interface {
 	CreateRelease(ctx context.Context, owner, repo string, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error)
 	GetLatestRelease(ctx context.Context, owner, repo string) (*github.RepositoryRelease, *github.Response, error)
+	GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*github.RepositoryRelease, *github.Response, error)
+	EditRelease(ctx context.Context, owner, repo string, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error)
+	DeleteRelease(ctx context.Context, owner, repo string, id int64) (*github.Response, error)
+
+	GetBranch(ctx context.Context, owner, repo, branch string) (*github.Branch, *github.Response, error)
+
+	GetContents(ctx context.Context, owner, repo, path string, opt *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+
+	GetCommit(ctx context.Context, owner, repo, sha string) (*github.RepositoryCommit, *github.Response, error)
 }
 
 // gitHubPullRequestsClient is a interface for calling GitHub API about pull requests
@@ -34,40 +53,139 @@ type gitHubPullRequestsClient interface {
 	List(ctx context.Context, owner string, repo string, opt *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error)
 
 	Create(ctx context.Context, owner string, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
+	Edit(ctx context.Context, owner string, repo string, number int, pull *github.PullRequest) (*github.PullRequest, *github.Response, error)
+	RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers github.ReviewersRequest) (*github.PullRequest, *github.Response, error)
+}
+
+// gitHubIssuesClient is a interface for calling GitHub API about issues and pull requests
+type gitHubIssuesClient interface {
+	AddLabelsToIssue(ctx context.Context, owner string, repo string, number int, labels []string) ([]*github.Label, *github.Response, error)
+	AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*github.Issue, *github.Response, error)
+	CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+}
+
+// gitHubGitClient is a interface for calling GitHub Git Data API
+type gitHubGitClient interface {
+	GetRef(ctx context.Context, owner string, repo string, ref string) (*github.Reference, *github.Response, error)
+	CreateRef(ctx context.Context, owner string, repo string, ref *github.Reference) (*github.Reference, *github.Response, error)
+	UpdateRef(ctx context.Context, owner string, repo string, ref *github.Reference, force bool) (*github.Reference, *github.Response, error)
+	GetTag(ctx context.Context, owner string, repo string, sha string) (*github.Tag, *github.Response, error)
+	DeleteRef(ctx context.Context, owner string, repo string, ref string) (*github.Response, error)
+
+	GetCommit(ctx context.Context, owner string, repo string, sha string) (*github.Commit, *github.Response, error)
+	CreateCommit(ctx context.Context, owner string, repo string, commit *github.Commit) (*github.Commit, *github.Response, error)
+	CreateTree(ctx context.Context, owner string, repo string, baseTree string, entries []*github.TreeEntry) (*github.Tree, *github.Response, error)
+}
+
+// gitHubReleaseNotesClient is a interface for calling GitHub API about generating release notes
+type gitHubReleaseNotesClient interface {
+	GenerateReleaseNotes(ctx context.Context, owner, repo string, opt *generateNotesOptions) (*releaseNotes, *github.Response, error)
+}
+
+// gitHubGraphQLClient is a interface for calling GitHub GraphQL API
+type gitHubGraphQLClient interface {
+	Query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error
+	Mutate(ctx context.Context, mutation string, variables map[string]interface{}, result interface{}) error
+}
+
+// generateNotesOptions represents parameters of the generate release notes API
+type generateNotesOptions struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	PreviousTagName string `json:"previous_tag_name,omitempty"`
+}
+
+// releaseNotes represents release notes generated by GitHub
+type releaseNotes struct {
+	Name string `json:"name"`
+	Body string `json:"body"`
+}
+
+// releaseNotesService calls the generate release notes API which go-github v32 doesn't support
+type releaseNotesService struct {
+	client *github.Client
+}
+
+// GenerateReleaseNotes generates release notes using .github/release.yml of the repository
+func (s *releaseNotesService) GenerateReleaseNotes(ctx context.Context, owner, repo string, opt *generateNotesOptions) (*releaseNotes, *github.Response, error) {
+	u := fmt.Sprintf("repos/%s/%s/releases/generate-notes", owner, repo)
+	req, err := s.client.NewRequest(http.MethodPost, u, opt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notes := &releaseNotes{}
+	resp, err := s.client.Do(ctx, req, notes)
+	if err != nil {
+		return nil, resp, err
+	}
+	return notes, resp, nil
 }
 
 // githubClient handles application logic using GitHub API
 type githubClient struct {
-	owner   string
-	repoCli gitHubRepositoriesClient
-	prCli   gitHubPullRequestsClient
+	owner    string
+	repoCli  gitHubRepositoriesClient
+	prCli    gitHubPullRequestsClient
+	gitCli   gitHubGitClient
+	notesCli gitHubReleaseNotesClient
+	// graphqlCli is required to check first-time contributors. For the other calls, REST API is used if it is nil.
+	graphqlCli gitHubGraphQLClient
+	issuesCli  gitHubIssuesClient
 }
 
 // newGitHubClientUsingEnv returns a pointer of githubClient
 // If accessToken is empty, you can't make any changes to the repository
-func newGitHubClientUsingEnv(owner, accessToken string) *githubClient {
-	ctx := context.Background()
+// Each API call is aborted after requestTimeout. If requestTimeout is zero, there is no timeout.
+// Idempotent API calls are retried on server errors and rate limits.
+func newGitHubClientUsingEnv(owner, accessToken string, requestTimeout time.Duration) *githubClient {
 	ts := oauth2.StaticTokenSource(&oauth2.Token{
 		AccessToken: accessToken,
 	})
-	tc := oauth2.NewClient(ctx, ts)
+	tc := oauth2.NewClient(context.Background(), ts)
+	tc.Transport = newRetryTransport(tc.Transport, requestTimeout)
 	client := github.NewClient(tc)
 
-	return newGitHubClient(owner, client.Repositories, client.PullRequests)
+	graphqlCli := &graphqlService{client: tc, endpoint: graphqlEndpoint}
+
+	return newGitHubClient(owner, gitHubClients{
+		repositories: client.Repositories,
+		pullRequests: client.PullRequests,
+		git:          client.Git,
+		releaseNotes: &releaseNotesService{client: client},
+		graphql:      graphqlCli,
+		issues:       client.Issues,
+	})
+}
+
+// gitHubClients is the clients of each GitHub API which githubClient calls
+// Tests set only the clients they expect to be called, and the others are left nil.
+type gitHubClients struct {
+	repositories gitHubRepositoriesClient
+	pullRequests gitHubPullRequestsClient
+	git          gitHubGitClient
+	releaseNotes gitHubReleaseNotesClient
+	// graphql is required to check first-time contributors. For the other calls, REST API is used if it is nil.
+	graphql gitHubGraphQLClient
+	issues  gitHubIssuesClient
 }
 
-func newGitHubClient(owner string, repoCli gitHubRepositoriesClient, prCli gitHubPullRequestsClient) *githubClient {
+func newGitHubClient(owner string, clients gitHubClients) *githubClient {
 	return &githubClient{
-		owner:   owner,
-		repoCli: repoCli,
-		prCli:   prCli,
+		owner:      owner,
+		repoCli:    clients.repositories,
+		prCli:      clients.pullRequests,
+		gitCli:     clients.git,
+		notesCli:   clients.releaseNotes,
+		graphqlCli: clients.graphql,
+		issuesCli:  clients.issues,
 	}
 }
 
-func (s *githubClient) getLastPublishedAndCurrentTag(repo string) (time.Time, string, error) {
+func (s *githubClient) getLastPublishedAndCurrentTag(ctx context.Context, repo string) (time.Time, string, error) {
 	after := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
 	tag := ""
-	release, err := s.getLatestRelease(repo)
+	release, err := s.getLatestRelease(ctx, repo)
 	if err != nil {
 		return after, "", fmt.Errorf("get latest release: %w", err)
 	}
@@ -78,22 +196,42 @@ func (s *githubClient) getLastPublishedAndCurrentTag(repo string) (time.Time, st
 }
 
 // createRelease creates GitHub release with a given tag
-func (s *githubClient) createRelease(repo, tagName, body string) (*github.RepositoryRelease, error) {
-	ctx := context.Background()
-	release, _, err := s.repoCli.CreateRelease(ctx, s.owner, repo, &github.RepositoryRelease{
+// If target is empty, the tag is created from the HEAD of the default branch
+func (s *githubClient) createRelease(ctx context.Context, repo, tagName, target, body string, prerelease bool) (*github.RepositoryRelease, error) {
+	newRelease := &github.RepositoryRelease{
 		TagName: github.String(tagName),
 		Name:    github.String(tagName),
 		Body:    github.String(body),
-	})
+	}
+	if target != "" {
+		newRelease.TargetCommitish = github.String(target)
+	}
+	if prerelease {
+		newRelease.Prerelease = github.Bool(true)
+	}
+	release, _, err := s.repoCli.CreateRelease(ctx, s.owner, repo, newRelease)
 	if err != nil {
 		return nil, fmt.Errorf("call creating release API: %w", err)
 	}
 	return release, nil
 }
 
+// generateReleaseNotes generates the release body of the new tag on GitHub
+// previousTag and target can be empty.
+func (s *githubClient) generateReleaseNotes(ctx context.Context, repo, tagName, previousTag, target string) (string, error) {
+	notes, _, err := s.notesCli.GenerateReleaseNotes(ctx, s.owner, repo, &generateNotesOptions{
+		TagName:         tagName,
+		TargetCommitish: target,
+		PreviousTagName: previousTag,
+	})
+	if err != nil {
+		return "", fmt.Errorf("call generating release notes API: %w", err)
+	}
+	return notes.Body, nil
+}
+
 // getLatestRelease gets the latest release
-func (s *githubClient) getLatestRelease(repo string) (*github.RepositoryRelease, error) {
-	ctx := context.Background()
+func (s *githubClient) getLatestRelease(ctx context.Context, repo string) (*github.RepositoryRelease, error) {
 	release, resp, err := s.repoCli.GetLatestRelease(ctx, s.owner, repo)
 	if err != nil {
 		if resp != nil && resp.StatusCode == http.StatusNotFound {
@@ -104,7 +242,389 @@ func (s *githubClient) getLatestRelease(repo string) (*github.RepositoryRelease,
 	return release, nil
 }
 
-func (s *githubClient) getMergedPRsAfter(repo string, after time.Time) ([]*github.PullRequest, error) {
+// getReleaseByTag returns the release of the tag
+func (s *githubClient) getReleaseByTag(ctx context.Context, repo, tag string) (*github.RepositoryRelease, error) {
+	release, resp, err := s.repoCli.GetReleaseByTag(ctx, s.owner, repo, tag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%s: %w", tag, errReleaseNotFound)
+		}
+		return nil, fmt.Errorf("call getting a release by tag API: %w", err)
+	}
+	return release, nil
+}
+
+// editRelease updates the release
+func (s *githubClient) editRelease(ctx context.Context, repo string, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, error) {
+	edited, _, err := s.repoCli.EditRelease(ctx, s.owner, repo, id, release)
+	if err != nil {
+		return nil, fmt.Errorf("call editing release API: %w", err)
+	}
+	return edited, nil
+}
+
+// deleteRelease deletes the release. The tag is left.
+func (s *githubClient) deleteRelease(ctx context.Context, repo string, id int64) error {
+	if _, err := s.repoCli.DeleteRelease(ctx, s.owner, repo, id); err != nil {
+		return fmt.Errorf("call deleting release API: %w", err)
+	}
+	return nil
+}
+
+// getTagCommitSHA returns the SHA of the commit which the tag points to
+// Annotated tags are resolved to the commit.
+func (s *githubClient) getTagCommitSHA(ctx context.Context, repo, tag string) (string, error) {
+	ref, resp, err := s.gitCli.GetRef(ctx, s.owner, repo, "tags/"+tag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%s: %w", tag, errTagNotFound)
+		}
+		return "", fmt.Errorf("call getting reference API: %w", err)
+	}
+
+	if ref.GetObject().GetType() != "tag" {
+		return ref.GetObject().GetSHA(), nil
+	}
+	annotated, _, err := s.gitCli.GetTag(ctx, s.owner, repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return "", fmt.Errorf("call getting tag API: %w", err)
+	}
+	return annotated.GetObject().GetSHA(), nil
+}
+
+// getCommitMessage returns the message of the commit
+func (s *githubClient) getCommitMessage(ctx context.Context, repo, sha string) (string, error) {
+	commit, _, err := s.gitCli.GetCommit(ctx, s.owner, repo, sha)
+	if err != nil {
+		return "", fmt.Errorf("call getting commit API: %w", err)
+	}
+	return commit.GetMessage(), nil
+}
+
+// tagExists reports whether the tag exists in the repository
+func (s *githubClient) tagExists(ctx context.Context, repo, tag string) (bool, error) {
+	if _, err := s.getTagCommitSHA(ctx, repo, tag); err != nil {
+		if errors.Is(err, errTagNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// getBranchSHA returns the SHA of the head commit of the branch
+func (s *githubClient) getBranchSHA(ctx context.Context, repo, branch string) (string, error) {
+	ref, _, err := s.gitCli.GetRef(ctx, s.owner, repo, "heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("call getting reference API: %w", err)
+	}
+	return ref.GetObject().GetSHA(), nil
+}
+
+// deleteTag deletes the tag
+func (s *githubClient) deleteTag(ctx context.Context, repo, tag string) error {
+	if _, err := s.gitCli.DeleteRef(ctx, s.owner, repo, "tags/"+tag); err != nil {
+		return fmt.Errorf("call deleting reference API: %w", err)
+	}
+	return nil
+}
+
+// isProtectedBranch reports whether the branch is protected
+func (s *githubClient) isProtectedBranch(ctx context.Context, repo, branch string) (bool, error) {
+	b, _, err := s.repoCli.GetBranch(ctx, s.owner, repo, branch)
+	if err != nil {
+		return false, fmt.Errorf("call getting branch API: %w", err)
+	}
+	return b.GetProtected(), nil
+}
+
+// getFile gets the content of the file at the given ref
+func (s *githubClient) getFile(ctx context.Context, repo, path, ref string) (string, error) {
+	file, _, resp, err := s.repoCli.GetContents(ctx, s.owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%s: %w", path, errFileNotFound)
+		}
+		return "", fmt.Errorf("call getting contents API: %w", err)
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s is not a file", path)
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("decode content: %w", err)
+	}
+	return content, nil
+}
+
+// getCommitDate returns the committed date of the tag, branch or commit
+func (s *githubClient) getCommitDate(ctx context.Context, repo, ref string) (time.Time, error) {
+	commit, resp, err := s.repoCli.GetCommit(ctx, s.owner, repo, ref)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity) {
+			return time.Time{}, fmt.Errorf("%s: %w", ref, errRefNotFound)
+		}
+		return time.Time{}, fmt.Errorf("call getting commit API: %w", err)
+	}
+	return commit.GetCommit().GetCommitter().GetDate(), nil
+}
+
+// commitFiles commits the changes on top of the branch in one commit and returns the SHA of the new commit
+func (s *githubClient) commitFiles(ctx context.Context, repo, branch, message string, changes []*fileChange) (string, error) {
+	ref, _, err := s.gitCli.GetRef(ctx, s.owner, repo, "heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("call getting reference API: %w", err)
+	}
+
+	sha, err := s.createCommit(ctx, repo, ref.GetObject().GetSHA(), message, changes)
+	if err != nil {
+		return "", err
+	}
+
+	_, _, err = s.gitCli.UpdateRef(ctx, s.owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}, false)
+	if err != nil {
+		return "", fmt.Errorf("call updating reference API: %w", err)
+	}
+	return sha, nil
+}
+
+// forceCommitFiles commits the changes on top of base and force-updates the branch to the commit
+// The branch is created if it doesn't exist. Commits which were on the branch are discarded.
+func (s *githubClient) forceCommitFiles(ctx context.Context, repo, base, branch, message string, changes []*fileChange) (string, error) {
+	baseSHA, err := s.getBranchSHA(ctx, repo, base)
+	if err != nil {
+		return "", err
+	}
+	sha, err := s.createCommit(ctx, repo, baseSHA, message, changes)
+	if err != nil {
+		return "", err
+	}
+
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+	_, resp, err := s.gitCli.GetRef(ctx, s.owner, repo, "heads/"+branch)
+	if err != nil {
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			return "", fmt.Errorf("call getting reference API: %w", err)
+		}
+		if _, _, err := s.gitCli.CreateRef(ctx, s.owner, repo, ref); err != nil {
+			return "", fmt.Errorf("call creating reference API: %w", err)
+		}
+		return sha, nil
+	}
+	if _, _, err := s.gitCli.UpdateRef(ctx, s.owner, repo, ref, true); err != nil {
+		return "", fmt.Errorf("call updating reference API: %w", err)
+	}
+	return sha, nil
+}
+
+// createCommit creates a commit of the changes whose parent is parentSHA without updating any branch
+func (s *githubClient) createCommit(ctx context.Context, repo, parentSHA, message string, changes []*fileChange) (string, error) {
+	parent, _, err := s.gitCli.GetCommit(ctx, s.owner, repo, parentSHA)
+	if err != nil {
+		return "", fmt.Errorf("call getting commit API: %w", err)
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(changes))
+	for _, c := range changes {
+		entries = append(entries, &github.TreeEntry{
+			Path:    github.String(c.path),
+			Mode:    github.String("100644"),
+			Type:    github.String("blob"),
+			Content: github.String(c.content),
+		})
+	}
+	tree, _, err := s.gitCli.CreateTree(ctx, s.owner, repo, parent.GetTree().GetSHA(), entries)
+	if err != nil {
+		return "", fmt.Errorf("call creating tree API: %w", err)
+	}
+
+	commit, _, err := s.gitCli.CreateCommit(ctx, s.owner, repo, &github.Commit{
+		Message: github.String(message),
+		Tree:    &github.Tree{SHA: tree.SHA},
+		Parents: []*github.Commit{{SHA: parent.SHA}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("call creating commit API: %w", err)
+	}
+	return commit.GetSHA(), nil
+}
+
+// createBranch creates a new branch from the HEAD of the base branch
+func (s *githubClient) createBranch(ctx context.Context, repo, base, branch string) error {
+	baseRef, _, err := s.gitCli.GetRef(ctx, s.owner, repo, "heads/"+base)
+	if err != nil {
+		return fmt.Errorf("call getting reference API: %w", err)
+	}
+
+	_, _, err = s.gitCli.CreateRef(ctx, s.owner, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.GetObject().SHA},
+	})
+	if err != nil {
+		return fmt.Errorf("call creating reference API: %w", err)
+	}
+	return nil
+}
+
+// listOpenPullRequests lists the open pull requests to the base branch
+func (s *githubClient) listOpenPullRequests(ctx context.Context, repo, base string) ([]*github.PullRequest, error) {
+	opt := &github.PullRequestListOptions{
+		State:       "open",
+		Base:        base,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var prList []*github.PullRequest
+	for {
+		prs, resp, err := s.prCli.List(ctx, s.owner, repo, opt)
+		if err != nil {
+			return nil, fmt.Errorf("call listing pull requests API: %w", err)
+		}
+		prList = append(prList, prs...)
+
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return prList, nil
+}
+
+// editPullRequest rewrites the title and the body of the pull request
+func (s *githubClient) editPullRequest(ctx context.Context, repo string, number int, title, body string) (*github.PullRequest, error) {
+	pr, _, err := s.prCli.Edit(ctx, s.owner, repo, number, &github.PullRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("call editing pull request API: %w", err)
+	}
+	return pr, nil
+}
+
+// closePullRequest comments on the pull request and closes it
+func (s *githubClient) closePullRequest(ctx context.Context, repo string, number int, comment string) error {
+	if _, _, err := s.issuesCli.CreateComment(ctx, s.owner, repo, number, &github.IssueComment{Body: github.String(comment)}); err != nil {
+		return fmt.Errorf("call creating comment API: %w", err)
+	}
+	if _, _, err := s.prCli.Edit(ctx, s.owner, repo, number, &github.PullRequest{State: github.String("closed")}); err != nil {
+		return fmt.Errorf("call editing pull request API: %w", err)
+	}
+	return nil
+}
+
+// createPullRequest creates a pull request from head to base
+func (s *githubClient) createPullRequest(ctx context.Context, repo, head, base, title, body string) (*github.PullRequest, error) {
+	pr, _, err := s.prCli.Create(ctx, s.owner, repo, &github.NewPullRequest{
+		Title: github.String(title),
+		Head:  github.String(head),
+		Base:  github.String(base),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("call creating pull request API: %w", err)
+	}
+	return pr, nil
+}
+
+// requestReviewers requests reviews of the pull request from the users and the teams
+func (s *githubClient) requestReviewers(ctx context.Context, repo string, number int, reviewers, teamReviewers []string) error {
+	if _, _, err := s.prCli.RequestReviewers(ctx, s.owner, repo, number, github.ReviewersRequest{
+		Reviewers:     reviewers,
+		TeamReviewers: teamReviewers,
+	}); err != nil {
+		return fmt.Errorf("call requesting reviewers API: %w", err)
+	}
+	return nil
+}
+
+// addLabels adds the labels to the issue or the pull request
+func (s *githubClient) addLabels(ctx context.Context, repo string, number int, labels []string) error {
+	if _, _, err := s.issuesCli.AddLabelsToIssue(ctx, s.owner, repo, number, labels); err != nil {
+		return fmt.Errorf("call adding labels API: %w", err)
+	}
+	return nil
+}
+
+// addAssignees assigns the users to the issue or the pull request
+func (s *githubClient) addAssignees(ctx context.Context, repo string, number int, assignees []string) error {
+	if _, _, err := s.issuesCli.AddAssignees(ctx, s.owner, repo, number, assignees); err != nil {
+		return fmt.Errorf("call adding assignees API: %w", err)
+	}
+	return nil
+}
+
+// enableAutoMerge merges the pull request with the method when the requirements are met
+// REST API doesn't support auto-merge, so it requires GraphQL API.
+func (s *githubClient) enableAutoMerge(ctx context.Context, pr *github.PullRequest, method string) error {
+	if s.graphqlCli == nil {
+		return errors.New("auto-merge requires GraphQL API")
+	}
+	variables := map[string]interface{}{
+		"pullRequestId": pr.GetNodeID(),
+		"mergeMethod":   strings.ToUpper(method),
+	}
+	if err := s.graphqlCli.Mutate(ctx, enableAutoMergeMutation, variables, &struct{}{}); err != nil {
+		return fmt.Errorf("call enabling auto-merge API: %w", err)
+	}
+	return nil
+}
+
+// mergedPRsBeforeQuery returns the search query of the pull requests of the user merged before the given time
+func (s *githubClient) mergedPRsBeforeQuery(repo, login string, before time.Time) string {
+	return fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s merged:<%s", s.owner, repo, login, before.UTC().Format(time.RFC3339))
+}
+
+// getMergedPRsAfter gets PRs merged into the base branch after the given time
+// It uses GraphQL API if available because it needs fewer round-trips.
+// The search index may lag behind the merges, so PRs merged within searchIndexLag are cross-checked with REST API.
+func (s *githubClient) getMergedPRsAfter(ctx context.Context, repo string, after time.Time) ([]*github.PullRequest, error) {
+	if s.graphqlCli == nil {
+		return s.listMergedPRsAfter(ctx, repo, after)
+	}
+
+	searched, err := s.searchMergedPRsAfter(ctx, repo, after)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-searchIndexLag)
+	if since.Before(after) {
+		since = after
+	}
+	recent, err := s.listMergedPRsAfter(ctx, repo, since)
+	if err != nil {
+		return nil, err
+	}
+	return mergePullRequests(searched, recent), nil
+}
+
+// mergePullRequests adds the pull requests which are missing in prs and sorts them by the merged date in descending order
+func mergePullRequests(prs, others []*github.PullRequest) []*github.PullRequest {
+	found := make(map[int]bool, len(prs))
+	for _, pr := range prs {
+		found[pr.GetNumber()] = true
+	}
+	for _, pr := range others {
+		if !found[pr.GetNumber()] {
+			prs = append(prs, pr)
+		}
+	}
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].GetMergedAt().After(prs[j].GetMergedAt())
+	})
+	return prs
+}
+
+// listMergedPRsAfter lists PRs merged into the base branch after the given time using REST API
+// The PRs are listed in the updated order, so it stops at the first PR updated before the given time.
+func (s *githubClient) listMergedPRsAfter(ctx context.Context, repo string, after time.Time) ([]*github.PullRequest, error) {
 	opt := &github.PullRequestListOptions{
 		State:       "closed",
 		Base:        baseBranch,
@@ -115,7 +635,6 @@ func (s *githubClient) getMergedPRsAfter(repo string, after time.Time) ([]*githu
 
 	var prList []*github.PullRequest
 	for {
-		ctx := context.Background()
 		prs, resp, err := s.prCli.List(ctx, s.owner, repo, opt)
 		if err != nil {
 			return nil, fmt.Errorf("call listing pull requests API: %w", err)
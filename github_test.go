@@ -1,9 +1,13 @@
 package mikku
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -81,9 +85,9 @@ func TestGitHubClient_createRelease(t *testing.T) {
 			cli := NewMockgitHubRepositoriesClient(ctrl)
 			cli = tt.injector(cli)
 
-			s := newGitHubClient("test-owner", cli, nil)
+			s := newGitHubClient("test-owner", gitHubClients{repositories: cli})
 
-			got, err := s.createRelease(tt.args.repo, tt.args.tagName, tt.args.body)
+			got, err := s.createRelease(context.Background(), tt.args.repo, tt.args.tagName, "", tt.args.body, false)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("githubClient.CreateRelease() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -98,6 +102,8 @@ func TestGitHubClient_createRelease(t *testing.T) {
 func TestGitHubService_getLatestRelease(t *testing.T) {
 	t.Parallel()
 
+	unhandledErr := &github.ErrorResponse{}
+
 	tests := []struct {
 		name     string
 		repo     string
@@ -117,8 +123,8 @@ func TestGitHubService_getLatestRelease(t *testing.T) {
 					Draft:           github.Bool(false),
 					Prerelease:      github.Bool(false),
 					ID:              github.Int64(19355655),
-					CreatedAt:       &github.Timestamp{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
-					PublishedAt:     &github.Timestamp{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+					CreatedAt:       &github.Timestamp{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+					PublishedAt:     &github.Timestamp{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
 					URL:             github.String("https://api.github.com/repos/test-repo/test-owner/releases/19355655"),
 					HTMLURL:         github.String("https://github.com/test-repo/test-owner/releases/tag/v1.0.0"),
 					NodeID:          github.String("MDc6UmVsZWFzZTE5MzU1NjU1"),
@@ -139,8 +145,8 @@ func TestGitHubService_getLatestRelease(t *testing.T) {
 			Draft:           github.Bool(false),
 			Prerelease:      github.Bool(false),
 			ID:              github.Int64(19355655),
-			CreatedAt:       &github.Timestamp{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
-			PublishedAt:     &github.Timestamp{time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			CreatedAt:       &github.Timestamp{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+			PublishedAt:     &github.Timestamp{Time: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
 			URL:             github.String("https://api.github.com/repos/test-repo/test-owner/releases/19355655"),
 			HTMLURL:         github.String("https://github.com/test-repo/test-owner/releases/tag/v1.0.0"),
 			NodeID:          github.String("MDc6UmVsZWFzZTE5MzU1NjU1"),
@@ -172,11 +178,11 @@ func TestGitHubService_getLatestRelease(t *testing.T) {
 						},
 						StatusCode: http.StatusInternalServerError,
 					},
-				}, &github.ErrorResponse{})
+				}, unhandledErr)
 				return cli
 			},
 			want:    nil,
-			wantErr: &github.ErrorResponse{},
+			wantErr: unhandledErr,
 		},
 	}
 	for _, tt := range tests {
@@ -186,11 +192,11 @@ func TestGitHubService_getLatestRelease(t *testing.T) {
 			cli := NewMockgitHubRepositoriesClient(ctrl)
 			cli = tt.injector(cli)
 
-			s := newGitHubClient("test-owner", cli, nil)
+			s := newGitHubClient("test-owner", gitHubClients{repositories: cli})
 
-			got, err := s.getLatestRelease(tt.repo)
+			got, err := s.getLatestRelease(context.Background(), tt.repo)
 			fmt.Printf("%#v\n", got)
-			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.As(err, &tt.wantErr)) {
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
 				t.Errorf("githubClient.getLatestRelease() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
@@ -353,8 +359,8 @@ func TestGitHubService_getMergedPRsAfter(t *testing.T) {
 			cli := NewMockgitHubPullRequestsClient(ctrl)
 			cli = tt.injector(cli)
 
-			s := newGitHubClient("test-owner", nil, cli)
-			got, err := s.getMergedPRsAfter(tt.repo, tt.after)
+			s := newGitHubClient("test-owner", gitHubClients{pullRequests: cli})
+			got, err := s.getMergedPRsAfter(context.Background(), tt.repo, tt.after)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("githubClient.getMergedPRsAfter() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -470,3 +476,42 @@ func Test_extractMergedPRsAfter(t *testing.T) {
 func timeToPointer(t time.Time) *time.Time {
 	return &t
 }
+
+func TestReleaseNotesService_GenerateReleaseNotes(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test-owner/test-repo/releases/generate-notes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want %s", r.Method, http.MethodPost)
+		}
+		got := &generateNotesOptions{}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Fatal(err)
+		}
+		want := &generateNotesOptions{TagName: "v1.1.0", TargetCommitish: "master", PreviousTagName: "v1.0.0"}
+		if !cmp.Equal(got, want) {
+			t.Errorf("request body diff=%s", cmp.Diff(got, want))
+		}
+		_, _ = fmt.Fprint(w, `{"name":"v1.1.0","body":"## What's Changed"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	s := &releaseNotesService{client: client}
+
+	got, _, err := s.GenerateReleaseNotes(context.Background(), "test-owner", "test-repo", &generateNotesOptions{
+		TagName:         "v1.1.0",
+		TargetCommitish: "master",
+		PreviousTagName: "v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("releaseNotesService.GenerateReleaseNotes() error = %v", err)
+	}
+	want := &releaseNotes{Name: "v1.1.0", Body: "## What's Changed"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("releaseNotesService.GenerateReleaseNotes() diff=%s", cmp.Diff(got, want))
+	}
+}
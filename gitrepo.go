@@ -0,0 +1,152 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const defaultGitRemote = "origin"
+
+// localRepository is a local git working copy of the manifest repository
+// The files are edited in a working tree detached at the base branch of the remote, so an existing checkout is left as it is.
+type localRepository struct {
+	// dir is the working tree
+	dir    string
+	remote string
+	config *GitConfig
+	// cleanup removes the temporary clone or worktree
+	cleanup func() error
+}
+
+// openLocalRepository clones the remote or adds a worktree to the existing checkout
+func openLocalRepository(ctx context.Context, gc *GitConfig) (*localRepository, error) {
+	tmp, err := ioutil.TempDir("", "mikku-")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+
+	if gc.Path == "" {
+		dir := filepath.Join(tmp, "repo")
+		// A shallow clone is enough to commit on top of the base branch, which matters for large repositories
+		if _, err := runGit(ctx, "", nil, "clone", "--quiet", "--depth=1", "--single-branch", "--branch", baseBranch, gc.URL, dir); err != nil {
+			_ = os.RemoveAll(tmp)
+			return nil, fmt.Errorf("clone %s: %w", gc.URL, err)
+		}
+		return &localRepository{
+			dir:     dir,
+			remote:  defaultGitRemote,
+			config:  gc,
+			cleanup: func() error { return os.RemoveAll(tmp) },
+		}, nil
+	}
+
+	remote := gc.Remote
+	if remote == "" {
+		remote = defaultGitRemote
+	}
+	if _, err := runGit(ctx, gc.Path, nil, "fetch", "--quiet", remote, baseBranch); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, fmt.Errorf("fetch %s: %w", remote, err)
+	}
+	dir := filepath.Join(tmp, "worktree")
+	if _, err := runGit(ctx, gc.Path, nil, "worktree", "add", "--quiet", "--detach", dir, "FETCH_HEAD"); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, fmt.Errorf("add worktree: %w", err)
+	}
+	return &localRepository{
+		dir:    dir,
+		remote: remote,
+		config: gc,
+		cleanup: func() error {
+			// The context may be canceled here, so the worktree is removed regardless of it
+			if _, err := runGit(context.Background(), gc.Path, nil, "worktree", "remove", "--force", dir); err != nil {
+				return fmt.Errorf("remove worktree %s: %w", dir, err)
+			}
+			return os.RemoveAll(tmp)
+		},
+	}, nil
+}
+
+// readFile implements readFileFunc
+func (r *localRepository) readFile(_ context.Context, path string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(r.dir, filepath.FromSlash(path)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s: %w", path, errFileNotFound)
+		}
+		return "", fmt.Errorf("read %s: %w", path, err)
+	}
+	return string(b), nil
+}
+
+// forceCommit commits the changes on top of the base branch and force-pushes it to the branch
+// It should be called once because the working tree stays at the new commit.
+func (r *localRepository) forceCommit(ctx context.Context, branch, message string, changes []*fileChange) error {
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		path := filepath.Join(r.dir, filepath.FromSlash(c.path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("create directory of %s: %w", c.path, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(c.content), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", c.path, err)
+		}
+		paths = append(paths, c.path)
+	}
+
+	if _, err := r.git(ctx, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	args := []string{"commit", "--quiet", "--message", message}
+	if r.config.SigningKey != "" {
+		args = append(args, "--gpg-sign="+r.config.SigningKey)
+	}
+	if _, err := r.git(ctx, args...); err != nil {
+		return err
+	}
+
+	// The branch always has a single commit on top of the base branch, so older commits are overwritten
+	if _, err := r.git(ctx, "push", "--quiet", "--force", r.remote, "HEAD:refs/heads/"+branch); err != nil {
+		return fmt.Errorf("push %s to %s: %w", branch, r.remote, err)
+	}
+	return nil
+}
+
+func (r *localRepository) close() error {
+	return r.cleanup()
+}
+
+// git runs the git command in the working tree as the configured author
+func (r *localRepository) git(ctx context.Context, args ...string) (string, error) {
+	var env []string
+	if r.config.AuthorName != "" {
+		env = append(env, "GIT_AUTHOR_NAME="+r.config.AuthorName, "GIT_COMMITTER_NAME="+r.config.AuthorName)
+	}
+	if r.config.AuthorEmail != "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+r.config.AuthorEmail, "GIT_COMMITTER_EMAIL="+r.config.AuthorEmail)
+	}
+	return runGit(ctx, r.dir, env, args...)
+}
+
+// runGit runs the git command and returns the stdout
+// The stderr is included in the error because git explains failures there.
+func runGit(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Prompts for credentials would block forever, so git fails instead
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
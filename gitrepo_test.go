@@ -0,0 +1,182 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKustomization = "images:\n  - name: ghcr.io/p1ass/api\n    newTag: v1.0.0\n"
+
+// newTestRemote creates a bare repository whose base branch has overlays/prod/kustomization.yaml
+func newTestRemote(t *testing.T) string {
+	t.Helper()
+
+	remote := filepath.Join(t.TempDir(), "manifests.git")
+	runTestGit(t, "", "init", "--quiet", "--bare", remote)
+
+	seed := t.TempDir()
+	runTestGit(t, seed, "init", "--quiet")
+	writeTestFile(t, filepath.Join(seed, "overlays", "prod", "kustomization.yaml"), testKustomization)
+	runTestGit(t, seed, "add", ".")
+	runTestGit(t, seed, "commit", "--quiet", "--message", "Initial commit")
+	runTestGit(t, seed, "push", "--quiet", remote, "HEAD:refs/heads/"+baseBranch)
+	return remote
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	out, err := runGit(context.Background(), dir, []string{
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	}, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(out)
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_localRepository(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tests := []struct {
+		name   string
+		config func(t *testing.T, remote string) *GitConfig
+	}{
+		{
+			name: "clone remote",
+			config: func(t *testing.T, remote string) *GitConfig {
+				return &GitConfig{URL: remote}
+			},
+		},
+		{
+			name: "use existing checkout",
+			config: func(t *testing.T, remote string) *GitConfig {
+				checkout := filepath.Join(t.TempDir(), "checkout")
+				runTestGit(t, "", "clone", "--quiet", remote, checkout)
+				runTestGit(t, checkout, "remote", "rename", "origin", "mirror")
+				return &GitConfig{Path: checkout, Remote: "mirror"}
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			remote := newTestRemote(t)
+			gc := tt.config(t, remote)
+			gc.AuthorName, gc.AuthorEmail = "mikku", "mikku@example.com"
+
+			// The branch is committed twice to check that it is force-pushed on top of the base branch
+			for _, tag := range []string{"v1.1.0", "v1.2.0"} {
+				r, err := openLocalRepository(ctx, gc)
+				if err != nil {
+					t.Fatalf("openLocalRepository() error = %v", err)
+				}
+
+				got, err := r.readFile(ctx, "overlays/prod/kustomization.yaml")
+				if err != nil {
+					t.Fatalf("readFile() error = %v", err)
+				}
+				if got != testKustomization {
+					t.Errorf("readFile() = %q, want %q", got, testKustomization)
+				}
+				if _, err := r.readFile(ctx, "overlays/dev/kustomization.yaml"); !errors.Is(err, errFileNotFound) {
+					t.Errorf("readFile() error = %v, want %v", err, errFileNotFound)
+				}
+
+				content := strings.Replace(testKustomization, "v1.0.0", tag, 1)
+				changes := []*fileChange{{path: "overlays/prod/kustomization.yaml", content: content}}
+				if err := r.forceCommit(ctx, "mikku/api/prod", "Update api to "+tag, changes); err != nil {
+					t.Fatalf("forceCommit() error = %v", err)
+				}
+
+				dir := r.dir
+				if err := r.close(); err != nil {
+					t.Fatalf("close() error = %v", err)
+				}
+				if _, err := os.Stat(dir); !os.IsNotExist(err) {
+					t.Errorf("working tree %s was not removed", dir)
+				}
+			}
+
+			base := runTestGit(t, remote, "rev-parse", baseBranch)
+			log := runTestGit(t, remote, "log", "-1", "--format=%an <%ae>|%cn <%ce>|%P|%s", "mikku/api/prod")
+			wantLog := "mikku <mikku@example.com>|mikku <mikku@example.com>|" + base + "|Update api to v1.2.0"
+			if log != wantLog {
+				t.Errorf("log = %q, want %q", log, wantLog)
+			}
+			content := runTestGit(t, remote, "show", "mikku/api/prod:overlays/prod/kustomization.yaml")
+			if want := strings.TrimSpace(strings.Replace(testKustomization, "v1.0.0", "v1.2.0", 1)); content != want {
+				t.Errorf("content = %q, want %q", content, want)
+			}
+
+			if gc.Path != "" {
+				if status := runTestGit(t, gc.Path, "status", "--porcelain"); status != "" {
+					t.Errorf("checkout was modified: %s", status)
+				}
+				if worktrees := runTestGit(t, gc.Path, "worktree", "list", "--porcelain"); strings.Count(worktrees, "worktree ") != 1 {
+					t.Errorf("worktree was not removed: %s", worktrees)
+				}
+			}
+		})
+	}
+}
+
+func Test_localRepository_signingKey(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+
+	ctx := context.Background()
+	remote := newTestRemote(t)
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen: %v: %s", err, out)
+	}
+
+	checkout := filepath.Join(t.TempDir(), "checkout")
+	runTestGit(t, "", "clone", "--quiet", remote, checkout)
+	runTestGit(t, checkout, "config", "gpg.format", "ssh")
+
+	r, err := openLocalRepository(ctx, &GitConfig{Path: checkout, AuthorName: "mikku", AuthorEmail: "mikku@example.com", SigningKey: key})
+	if err != nil {
+		t.Fatalf("openLocalRepository() error = %v", err)
+	}
+	defer r.close()
+
+	changes := []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "images: []\n"}}
+	if err := r.forceCommit(ctx, "mikku/api/prod", "Update api to v1.1.0", changes); err != nil {
+		t.Fatalf("forceCommit() error = %v", err)
+	}
+
+	commit := runTestGit(t, remote, "cat-file", "commit", "mikku/api/prod")
+	if !strings.Contains(commit, "-----BEGIN SSH SIGNATURE-----") {
+		t.Errorf("commit is not signed:\n%s", commit)
+	}
+}
@@ -7,7 +7,9 @@ require (
 	github.com/google/go-cmp v0.5.6
 	github.com/google/go-github/v32 v32.1.0
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/mod v0.4.2
 	golang.org/x/oauth2 v0.0.0-20190604053449-0f29369cfe45
-
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,69 @@
+package mikku
+
+import (
+	"fmt"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const goModFile = "go.mod"
+
+// goModule represents the module path and the requirements in go.mod
+type goModule struct {
+	path    string
+	content string
+	// requires maps the required module path to its version
+	requires map[string]string
+}
+
+// parseGoMod parses the content of go.mod
+func parseGoMod(content string) (*goModule, error) {
+	f, err := modfile.ParseLax(goModFile, []byte(content), nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", goModFile, err)
+	}
+	if f.Module == nil {
+		return nil, fmt.Errorf("%s: module directive not found", goModFile)
+	}
+
+	m := &goModule{
+		path:     f.Module.Mod.Path,
+		content:  content,
+		requires: make(map[string]string, len(f.Require)),
+	}
+	for _, r := range f.Require {
+		m.requires[r.Mod.Path] = r.Mod.Version
+	}
+	return m, nil
+}
+
+// bumpGoModRequires updates the versions of the required modules
+// versions maps the module path to the new version.
+func bumpGoModRequires(content string, versions map[string]string) (string, error) {
+	f, err := modfile.Parse(goModFile, []byte(content), nil)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", goModFile, err)
+	}
+
+	for path, version := range versions {
+		if !semver.IsValid(version) {
+			return "", fmt.Errorf("%s: %s is not a valid module version", path, version)
+		}
+		// Ex. v2.0.0 can't be required as github.com/owner/repo without /v2 suffix
+		_, pathMajor, _ := module.SplitPathVersion(path)
+		if err := module.CheckPathMajor(version, pathMajor); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+		if err := f.AddRequire(path, version); err != nil {
+			return "", fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	b, err := f.Format()
+	if err != nil {
+		return "", fmt.Errorf("format %s: %w", goModFile, err)
+	}
+	return string(b), nil
+}
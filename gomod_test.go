@@ -0,0 +1,89 @@
+package mikku
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testGoMod = `module github.com/test-owner/service
+
+go 1.15
+
+require (
+	github.com/test-owner/lib-core v1.0.0
+	github.com/test-owner/lib-http/v2 v2.1.0 // indirect
+)
+`
+
+func Test_parseGoMod(t *testing.T) {
+	t.Parallel()
+
+	got, err := parseGoMod(testGoMod)
+	if err != nil {
+		t.Fatalf("parseGoMod() error = %v", err)
+	}
+	if got.path != "github.com/test-owner/service" {
+		t.Errorf("parseGoMod() path = %s, want github.com/test-owner/service", got.path)
+	}
+	want := map[string]string{
+		"github.com/test-owner/lib-core":    "v1.0.0",
+		"github.com/test-owner/lib-http/v2": "v2.1.0",
+	}
+	if !cmp.Equal(got.requires, want) {
+		t.Errorf("parseGoMod() requires diff=%s", cmp.Diff(got.requires, want))
+	}
+}
+
+func Test_bumpGoModRequires(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		versions map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "bump versions keeping comments",
+			versions: map[string]string{
+				"github.com/test-owner/lib-core":    "v1.1.0",
+				"github.com/test-owner/lib-http/v2": "v2.2.0",
+			},
+			want: `module github.com/test-owner/service
+
+go 1.15
+
+require (
+	github.com/test-owner/lib-core v1.1.0
+	github.com/test-owner/lib-http/v2 v2.2.0 // indirect
+)
+`,
+			wantErr: false,
+		},
+		{
+			name:     "major version doesn't match module path",
+			versions: map[string]string{"github.com/test-owner/lib-core": "v2.0.0"},
+			want:     "",
+			wantErr:  true,
+		},
+		{
+			name:     "not semantic version",
+			versions: map[string]string{"github.com/test-owner/lib-core": "release-1"},
+			want:     "",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bumpGoModRequires(testGoMod, tt.versions)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("bumpGoModRequires() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("bumpGoModRequires() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
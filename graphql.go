@@ -0,0 +1,317 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	graphqlEndpoint = "https://api.github.com/graphql"
+	// graphqlPerPage is the maximum page size of the GraphQL API
+	graphqlPerPage = 100
+	// searchResultLimit is the maximum number of results the search API returns for a query
+	searchResultLimit = 1000
+	// ghostLogin is the user which GitHub shows instead of deleted users
+	ghostLogin = "ghost"
+	// contributorsPerQuery is the number of users whose contributions are searched in one query
+	contributorsPerQuery = 20
+
+	mergedPRsQuery = `query($query: String!, $first: Int!, $cursor: String) {
+  search(query: $query, type: ISSUE, first: $first, after: $cursor) {
+    issueCount
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      ... on PullRequest {
+        number
+        title
+        body
+        url
+        mergedAt
+        updatedAt
+        headRefName
+        author {
+          login
+          url
+        }
+        labels(first: 100) {
+          nodes {
+            name
+          }
+        }
+      }
+    }
+  }
+}`
+)
+
+const enableAutoMergeMutation = `mutation($pullRequestId: ID!, $mergeMethod: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $pullRequestId, mergeMethod: $mergeMethod}) {
+    clientMutationId
+  }
+}`
+
+// graphqlService calls GitHub GraphQL API
+type graphqlService struct {
+	client   *http.Client
+	endpoint string
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Query sends the query and decodes the data into result
+// Queries are read-only, so they are retried like GET requests.
+func (s *graphqlService) Query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	return s.post(withIdempotent(ctx), query, variables, result)
+}
+
+// Mutate sends the mutation and decodes the data into result
+// Unlike queries, mutations are not retried.
+func (s *graphqlService) Mutate(ctx context.Context, mutation string, variables map[string]interface{}, result interface{}) error {
+	return s.post(ctx, mutation, variables, result)
+}
+
+func (s *graphqlService) post(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	b, err := json.Marshal(&graphqlRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	gr := &graphqlResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(gr); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if len(gr.Errors) > 0 {
+		messages := make([]string, 0, len(gr.Errors))
+		for _, e := range gr.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("graphql error: %s", strings.Join(messages, ", "))
+	}
+
+	if err := json.Unmarshal(gr.Data, result); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+	return nil
+}
+
+// mergedPRsResult represents the result of mergedPRsQuery
+type mergedPRsResult struct {
+	Search struct {
+		IssueCount int `json:"issueCount"`
+		PageInfo   struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+		Nodes []*graphqlPullRequest `json:"nodes"`
+	} `json:"search"`
+}
+
+type graphqlPullRequest struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	URL         string     `json:"url"`
+	MergedAt    *time.Time `json:"mergedAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+	HeadRefName string     `json:"headRefName"`
+	Author      *struct {
+		Login string `json:"login"`
+		URL   string `json:"url"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+}
+
+// toPullRequest converts the GraphQL node to the REST API representation
+func (p *graphqlPullRequest) toPullRequest() *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:    github.Int(p.Number),
+		Title:     github.String(p.Title),
+		Body:      github.String(p.Body),
+		HTMLURL:   github.String(p.URL),
+		MergedAt:  p.MergedAt,
+		UpdatedAt: p.UpdatedAt,
+		Head:      &github.PullRequestBranch{Ref: github.String(p.HeadRefName)},
+	}
+	// Deleted users are returned as null. REST API returns them as the ghost user, and templates expect the user.
+	pr.User = &github.User{
+		Login:   github.String(ghostLogin),
+		HTMLURL: github.String("https://github.com/" + ghostLogin),
+	}
+	if p.Author != nil {
+		pr.User = &github.User{
+			Login:   github.String(p.Author.Login),
+			HTMLURL: github.String(p.Author.URL),
+		}
+	}
+	for _, l := range p.Labels.Nodes {
+		pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l.Name)})
+	}
+	return pr
+}
+
+// searchMergedPRsAfter finds PRs merged into the base branch after the given time using GraphQL API
+// Unlike listing PRs sorted by the updated date, PRs edited after the merge are never missed.
+func (s *githubClient) searchMergedPRsAfter(ctx context.Context, repo string, after time.Time) ([]*github.PullRequest, error) {
+	prList, err := s.searchMergedPRsBetween(ctx, repo, after.UTC().Truncate(time.Second), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(prList, func(i, j int) bool {
+		return prList[i].GetMergedAt().After(prList[j].GetMergedAt())
+	})
+	return prList, nil
+}
+
+// searchMergedPRsBetween finds PRs merged after from and until to. If to is zero, there is no upper bound.
+// The search API returns up to 1000 results, so the range is split in half while it has more PRs than that.
+func (s *githubClient) searchMergedPRsBetween(ctx context.Context, repo string, from, to time.Time) ([]*github.PullRequest, error) {
+	merged := ">" + from.Format(time.RFC3339)
+	if !to.IsZero() {
+		// The range is inclusive, so PRs merged at from are filtered below
+		merged = from.Format(time.RFC3339) + ".." + to.Format(time.RFC3339)
+	}
+	query := fmt.Sprintf("repo:%s/%s is:pr is:merged base:%s merged:%s", s.owner, repo, baseBranch, merged)
+	variables := map[string]interface{}{
+		"query": query,
+		"first": graphqlPerPage,
+	}
+
+	var (
+		prList  []*github.PullRequest
+		fetched int
+	)
+	for {
+		result := &mergedPRsResult{}
+		if err := s.graphqlCli.Query(ctx, mergedPRsQuery, variables, result); err != nil {
+			return nil, fmt.Errorf("call searching pull requests GraphQL API: %w", err)
+		}
+
+		if count := result.Search.IssueCount; count > searchResultLimit {
+			upper := to
+			if upper.IsZero() {
+				upper = time.Now().UTC().Truncate(time.Second)
+			}
+			mid := from.Add(upper.Sub(from) / 2).Truncate(time.Second)
+			if !mid.After(from) {
+				return nil, fmt.Errorf("%d pull requests were merged at %s, which exceeds the search limit", count, from.Format(time.RFC3339))
+			}
+			older, err := s.searchMergedPRsBetween(ctx, repo, from, mid)
+			if err != nil {
+				return nil, err
+			}
+			newer, err := s.searchMergedPRsBetween(ctx, repo, mid, to)
+			if err != nil {
+				return nil, err
+			}
+			return append(older, newer...), nil
+		}
+
+		fetched += len(result.Search.Nodes)
+		for _, node := range result.Search.Nodes {
+			// The search results may contain issues which don't match `... on PullRequest`
+			if node.Number == 0 || node.MergedAt == nil || !node.MergedAt.After(from) {
+				continue
+			}
+			prList = append(prList, node.toPullRequest())
+		}
+
+		if !result.Search.PageInfo.HasNextPage {
+			// The list would be silently truncated if the search stopped early
+			if fetched < result.Search.IssueCount {
+				return nil, fmt.Errorf("search returned %d of %d pull requests for %q", fetched, result.Search.IssueCount, query)
+			}
+			break
+		}
+		variables["cursor"] = result.Search.PageInfo.EndCursor
+	}
+	return prList, nil
+}
+
+// contributionsQuery returns the query counting the results of n searches at once
+// The searches are aliased as s0, s1, ... and their queries are given as the variables $q0, $q1, ...
+func contributionsQuery(n int) string {
+	var b strings.Builder
+	b.WriteString("query(")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "$q%d: String!", i)
+	}
+	b.WriteString(") {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "  s%d: search(query: $q%d, type: ISSUE, first: 1) {\n    issueCount\n  }\n", i, i)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// searchContributionsBefore reports whether each user had a merged pull request before the given time
+// The searches of contributorsPerQuery users are sent in one query, so many contributors don't exhaust the rate limit of the search API.
+func (s *githubClient) searchContributionsBefore(ctx context.Context, repo string, logins []string, before time.Time) (map[string]bool, error) {
+	contributed := map[string]bool{}
+	for start := 0; start < len(logins); start += contributorsPerQuery {
+		end := start + contributorsPerQuery
+		if end > len(logins) {
+			end = len(logins)
+		}
+		batch := logins[start:end]
+
+		variables := map[string]interface{}{}
+		for i, login := range batch {
+			variables[fmt.Sprintf("q%d", i)] = s.mergedPRsBeforeQuery(repo, login, before)
+		}
+		result := map[string]struct {
+			IssueCount int `json:"issueCount"`
+		}{}
+		if err := s.graphqlCli.Query(ctx, contributionsQuery(len(batch)), variables, &result); err != nil {
+			return nil, fmt.Errorf("call searching contributions GraphQL API: %w", err)
+		}
+		for i, login := range batch {
+			contributed[login] = result[fmt.Sprintf("s%d", i)].IssueCount > 0
+		}
+	}
+	return contributed, nil
+}
@@ -0,0 +1,316 @@
+package mikku
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func TestGraphqlService_Query(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		response string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "data",
+			response: `{"data":{"viewer":"test-owner"}}`,
+			want:     map[string]string{"viewer": "test-owner"},
+			wantErr:  false,
+		},
+		{
+			name:     "errors",
+			response: `{"data":null,"errors":[{"message":"Something went wrong"}]}`,
+			want:     map[string]string{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req := &graphqlRequest{}
+				if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+					t.Fatal(err)
+				}
+				if req.Query != "query { viewer }" || req.Variables["name"] != "value" {
+					t.Errorf("unexpected request %+v", req)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			s := &graphqlService{client: server.Client(), endpoint: server.URL}
+			got := map[string]string{}
+			err := s.Query(context.Background(), "query { viewer }", map[string]interface{}{"name": "value"}, &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("graphqlService.Query() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("graphqlService.Query() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestGitHubService_getMergedPRsAfterUsingGraphQL(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cli := NewMockgitHubGraphQLClient(ctrl)
+
+	respond := func(data string) func(context.Context, string, map[string]interface{}, interface{}) error {
+		return func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+			return json.Unmarshal([]byte(data), result)
+		}
+	}
+
+	gomock.InOrder(
+		cli.EXPECT().Query(gomock.Any(), mergedPRsQuery, map[string]interface{}{
+			"query": "repo:test-owner/test-repo is:pr is:merged base:master merged:>2019-01-01T00:00:00Z",
+			"first": graphqlPerPage,
+		}, gomock.Any()).DoAndReturn(respond(`{"search":{"pageInfo":{"hasNextPage":true,"endCursor":"cursor-1"},"nodes":[
+			{"number":1,"title":"First","url":"https://github.com/test-owner/test-repo/pull/1","mergedAt":"2019-01-02T00:00:00Z","updatedAt":"2019-01-05T00:00:00Z","headRefName":"fix-bug",
+			 "author":{"login":"test-owner","url":"https://github.com/test-owner"},"labels":{"nodes":[{"name":"bug"}]}},
+			{}
+		]}}`)),
+		cli.EXPECT().Query(gomock.Any(), mergedPRsQuery, map[string]interface{}{
+			"query":  "repo:test-owner/test-repo is:pr is:merged base:master merged:>2019-01-01T00:00:00Z",
+			"first":  graphqlPerPage,
+			"cursor": "cursor-1",
+		}, gomock.Any()).DoAndReturn(respond(`{"search":{"pageInfo":{"hasNextPage":false,"endCursor":"cursor-2"},"nodes":[
+			{"number":2,"title":"Second","url":"https://github.com/test-owner/test-repo/pull/2","mergedAt":"2019-01-03T00:00:00Z","updatedAt":"2019-01-03T00:00:00Z",
+			 "author":null,"labels":{"nodes":[]}}
+		]}}`)),
+	)
+	prCli := NewMockgitHubPullRequestsClient(ctrl)
+	prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, &github.Response{}, nil)
+
+	s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, graphql: cli})
+	got, err := s.getMergedPRsAfter(context.Background(), "test-repo", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("githubClient.getMergedPRsAfter() error = %v", err)
+	}
+
+	want := []*github.PullRequest{
+		{
+			Number:    github.Int(2),
+			Title:     github.String("Second"),
+			Body:      github.String(""),
+			HTMLURL:   github.String("https://github.com/test-owner/test-repo/pull/2"),
+			MergedAt:  timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+			UpdatedAt: timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+			Head:      &github.PullRequestBranch{Ref: github.String("")},
+			User: &github.User{
+				Login:   github.String("ghost"),
+				HTMLURL: github.String("https://github.com/ghost"),
+			},
+		},
+		{
+			Number:    github.Int(1),
+			Title:     github.String("First"),
+			Body:      github.String(""),
+			HTMLURL:   github.String("https://github.com/test-owner/test-repo/pull/1"),
+			MergedAt:  timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+			UpdatedAt: timeToPointer(time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC)),
+			Head:      &github.PullRequestBranch{Ref: github.String("fix-bug")},
+			User: &github.User{
+				Login:   github.String("test-owner"),
+				HTMLURL: github.String("https://github.com/test-owner"),
+			},
+			Labels: []*github.Label{{Name: github.String("bug")}},
+		},
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("githubClient.getMergedPRsAfter() diff=%s", cmp.Diff(got, want))
+	}
+
+	// Pull requests of deleted users are rendered as @ghost instead of failing the release
+	body, err := generateReleaseBody(&releaseNote{PullRequests: got})
+	if err != nil {
+		t.Fatalf("generateReleaseBody() error = %v", err)
+	}
+	if !strings.Contains(body, "- Second (#2) by @ghost") {
+		t.Errorf("generateReleaseBody() = %s, want to contain @ghost", body)
+	}
+}
+
+func TestGitHubService_getMergedPRsAfterUsingGraphQL_searchIndexLag(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cli := NewMockgitHubGraphQLClient(ctrl)
+	prCli := NewMockgitHubPullRequestsClient(ctrl)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	indexed := now.Add(-time.Hour).Format(time.RFC3339)
+	cli.EXPECT().Query(gomock.Any(), mergedPRsQuery, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+			return json.Unmarshal([]byte(`{"search":{"issueCount":2,"pageInfo":{"hasNextPage":false},"nodes":[
+				{"number":2,"title":"Indexed recently","mergedAt":"`+now.Add(-5*time.Minute).Format(time.RFC3339)+`"},
+				{"number":1,"title":"Indexed","mergedAt":"`+indexed+`"}
+			]}}`), result)
+		})
+	// PR 3 is merged but not indexed yet. PR 1 is updated before the window, so the listing stops there.
+	prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return([]*github.PullRequest{
+		{Number: github.Int(3), MergedAt: timeToPointer(now.Add(-time.Minute)), UpdatedAt: timeToPointer(now.Add(-time.Minute))},
+		{Number: github.Int(2), MergedAt: timeToPointer(now.Add(-5 * time.Minute)), UpdatedAt: timeToPointer(now.Add(-5 * time.Minute))},
+		{Number: github.Int(1), MergedAt: timeToPointer(now.Add(-time.Hour)), UpdatedAt: timeToPointer(now.Add(-time.Hour))},
+	}, &github.Response{NextPage: 2}, nil)
+
+	s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, graphql: cli})
+	got, err := s.getMergedPRsAfter(context.Background(), "test-repo", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("githubClient.getMergedPRsAfter() error = %v", err)
+	}
+	var numbers []int
+	for _, pr := range got {
+		numbers = append(numbers, pr.GetNumber())
+	}
+	if want := []int{3, 2, 1}; !cmp.Equal(numbers, want) {
+		t.Errorf("githubClient.getMergedPRsAfter() numbers = %v, want %v", numbers, want)
+	}
+}
+
+func TestGitHubService_getMergedPRsAfterUsingGraphQL_searchLimit(t *testing.T) {
+	t.Parallel()
+
+	after := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := func(number int, mergedAt string) string {
+		return fmt.Sprintf(`{"number":%d,"title":"PR","url":"u","mergedAt":%q,"author":null,"labels":{"nodes":[]}}`, number, mergedAt)
+	}
+
+	t.Run("split range exceeding the limit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cli := NewMockgitHubGraphQLClient(ctrl)
+
+		var queries []string
+		cli.EXPECT().Query(gomock.Any(), mergedPRsQuery, gomock.Any(), gomock.Any()).Times(3).
+			DoAndReturn(func(_ context.Context, _ string, variables map[string]interface{}, result interface{}) error {
+				query := variables["query"].(string)
+				queries = append(queries, query)
+				switch {
+				case strings.HasSuffix(query, "merged:>2019-01-01T00:00:00Z"):
+					return json.Unmarshal([]byte(`{"search":{"issueCount":1500,"pageInfo":{"hasNextPage":true},"nodes":[]}}`), result)
+				case strings.Contains(query, "merged:2019-01-01T00:00:00Z.."):
+					// PRs merged at the lower bound are excluded like `merged:>`
+					return json.Unmarshal([]byte(`{"search":{"issueCount":2,"pageInfo":{"hasNextPage":false},"nodes":[`+
+						node(1, "2019-01-01T00:00:00Z")+`,`+node(2, "2019-01-02T00:00:00Z")+`]}}`), result)
+				default:
+					return json.Unmarshal([]byte(`{"search":{"issueCount":1,"pageInfo":{"hasNextPage":false},"nodes":[`+
+						node(3, time.Now().UTC().Format(time.RFC3339))+`]}}`), result)
+				}
+			})
+		prCli := NewMockgitHubPullRequestsClient(ctrl)
+		prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, &github.Response{}, nil)
+
+		s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, graphql: cli})
+		got, err := s.getMergedPRsAfter(context.Background(), "test-repo", after)
+		if err != nil {
+			t.Fatalf("githubClient.getMergedPRsAfter() error = %v, queries = %v", err, queries)
+		}
+		var numbers []int
+		for _, pr := range got {
+			numbers = append(numbers, pr.GetNumber())
+		}
+		if want := []int{3, 2}; !cmp.Equal(numbers, want) {
+			t.Errorf("githubClient.getMergedPRsAfter() numbers = %v, want %v, queries = %v", numbers, want, queries)
+		}
+	})
+
+	t.Run("truncated results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		cli := NewMockgitHubGraphQLClient(ctrl)
+
+		cli.EXPECT().Query(gomock.Any(), mergedPRsQuery, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+				return json.Unmarshal([]byte(`{"search":{"issueCount":5,"pageInfo":{"hasNextPage":false},"nodes":[`+node(1, "2019-01-02T00:00:00Z")+`]}}`), result)
+			})
+
+		s := newGitHubClient("test-owner", gitHubClients{graphql: cli})
+		if _, err := s.getMergedPRsAfter(context.Background(), "test-repo", after); err == nil {
+			t.Errorf("githubClient.getMergedPRsAfter() error = nil, want error")
+		}
+	})
+}
+
+func TestGitHubService_searchContributionsBefore(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cli := NewMockgitHubGraphQLClient(ctrl)
+
+	before := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	logins := make([]string, contributorsPerQuery+1)
+	for i := range logins {
+		logins[i] = fmt.Sprintf("user%d", i)
+	}
+	query := func(login string) string {
+		return "repo:test-owner/test-repo is:pr is:merged author:" + login + " merged:<2019-01-01T00:00:00Z"
+	}
+
+	firstBatch := map[string]interface{}{}
+	for i := 0; i < contributorsPerQuery; i++ {
+		firstBatch[fmt.Sprintf("q%d", i)] = query(logins[i])
+	}
+	gomock.InOrder(
+		cli.EXPECT().Query(gomock.Any(), contributionsQuery(contributorsPerQuery), firstBatch, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+				return json.Unmarshal([]byte(`{"s0":{"issueCount":3},"s1":{"issueCount":0}}`), result)
+			}),
+		cli.EXPECT().Query(gomock.Any(), contributionsQuery(1), map[string]interface{}{"q0": query(logins[contributorsPerQuery])}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+				return json.Unmarshal([]byte(`{"s0":{"issueCount":1}}`), result)
+			}),
+	)
+
+	s := newGitHubClient("test-owner", gitHubClients{graphql: cli})
+	got, err := s.searchContributionsBefore(context.Background(), "test-repo", logins, before)
+	if err != nil {
+		t.Fatalf("searchContributionsBefore() error = %v", err)
+	}
+	if len(got) != len(logins) {
+		t.Fatalf("searchContributionsBefore() returned %d users, want %d", len(got), len(logins))
+	}
+	for login, want := range map[string]bool{"user0": true, "user1": false, "user2": false, logins[contributorsPerQuery]: true} {
+		if got[login] != want {
+			t.Errorf("searchContributionsBefore()[%s] = %v, want %v", login, got[login], want)
+		}
+	}
+}
+
+func Test_contributionsQuery(t *testing.T) {
+	t.Parallel()
+
+	want := `query($q0: String!, $q1: String!) {
+  s0: search(query: $q0, type: ISSUE, first: 1) {
+    issueCount
+  }
+  s1: search(query: $q1, type: ISSUE, first: 1) {
+    issueCount
+  }
+}`
+	if got := contributionsQuery(2); got != want {
+		t.Errorf("contributionsQuery() diff=%s", cmp.Diff(got, want))
+	}
+}
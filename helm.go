@@ -0,0 +1,70 @@
+package mikku
+
+import (
+	"fmt"
+	"strings"
+)
+
+const chartAppVersionKey = "appVersion"
+
+// parseHelmValuesTarget splits the target formatted as `file:yamlpath`
+func parseHelmValuesTarget(target string) (string, string, error) {
+	idx := strings.LastIndex(target, ":")
+	if idx <= 0 || idx == len(target)-1 {
+		return "", "", fmt.Errorf("%s: helm values should be formatted as file:yamlpath", target)
+	}
+	return target[:idx], target[idx+1:], nil
+}
+
+// updateHelmValue replaces the value at the dot-separated path with the tag
+// Only the value is rewritten, so the file is not reformatted.
+func updateHelmValue(content, key, tag string) (string, error) {
+	node, err := findYAMLScalar([]byte(content), key)
+	if err != nil {
+		return "", fmt.Errorf("find %s: %w", key, err)
+	}
+	if node.Value == tag {
+		return content, nil
+	}
+
+	updated, err := replaceYAMLString([]byte(content), node, tag)
+	if err != nil {
+		return "", fmt.Errorf("replace %s: %w", key, err)
+	}
+	return string(updated), nil
+}
+
+// updateChartAppVersion replaces appVersion of Chart.yaml with the tag
+// The `v` prefix is kept only if the current appVersion has it.
+func updateChartAppVersion(content, tag string) (string, error) {
+	node, err := findYAMLScalar([]byte(content), chartAppVersionKey)
+	if err != nil {
+		return "", fmt.Errorf("find %s: %w", chartAppVersionKey, err)
+	}
+	return updateHelmValue(content, chartAppVersionKey, versionLike(node.Value, tag))
+}
+
+// helmImageVariant returns the image next to the tag if it has the same repository as the image but doesn't match it
+// The image is read from `repository` and `registry` in the same mapping as the tag. Ex. image.repository for image.tag
+// It is empty if the values don't name the image, so the tag is updated as configured.
+func helmImageVariant(content, key, image string) string {
+	parent := ""
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		parent = key[:i+1]
+	}
+	repository, err := findYAMLScalar([]byte(content), parent+"repository")
+	if err != nil || repository.Value == "" {
+		return ""
+	}
+	named := repository.Value
+	if registry, err := findYAMLScalar([]byte(content), parent+"registry"); err == nil && registry.Value != "" {
+		named = registry.Value + "/" + named
+	}
+
+	host, repo := parseImageReference(image)
+	namedHost, namedRepo := parseImageReference(named)
+	if namedRepo != repo || namedHost == host {
+		return ""
+	}
+	return named
+}
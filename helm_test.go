@@ -0,0 +1,249 @@
+package mikku
+
+import (
+	"testing"
+)
+
+func Test_parseHelmValuesTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		target   string
+		wantFile string
+		wantKey  string
+		wantErr  bool
+	}{
+		{
+			name:     "file and key",
+			target:   "charts/app/values.yaml:image.tag",
+			wantFile: "charts/app/values.yaml",
+			wantKey:  "image.tag",
+			wantErr:  false,
+		},
+		{
+			name:     "split on last colon",
+			target:   "env:prod/values.yaml:app.image.tag",
+			wantFile: "env:prod/values.yaml",
+			wantKey:  "app.image.tag",
+			wantErr:  false,
+		},
+		{
+			name:    "no key",
+			target:  "values.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			target:  "values.yaml:",
+			wantErr: true,
+		},
+		{
+			name:    "empty file",
+			target:  ":image.tag",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			file, key, err := parseHelmValuesTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseHelmValuesTarget() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if file != tt.wantFile || key != tt.wantKey {
+				t.Errorf("parseHelmValuesTarget() = (%s, %s), want (%s, %s)", file, key, tt.wantFile, tt.wantKey)
+			}
+		})
+	}
+}
+
+func Test_updateHelmValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		tag     string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "replace nested tag in place",
+			content: `# default values
+image:
+  repository: ghcr.io/p1ass/mikku
+  tag: "v1.0.0" # updated by mikku
+replicaCount: 2
+`,
+			key: "image.tag",
+			tag: "v1.1.0",
+			want: `# default values
+image:
+  repository: ghcr.io/p1ass/mikku
+  tag: "v1.1.0" # updated by mikku
+replicaCount: 2
+`,
+			wantErr: false,
+		},
+		{
+			name: "replace tag in list",
+			content: `containers:
+  - name: app
+    tag: v1.0.0
+`,
+			key: "containers.0.tag",
+			tag: "v1.1.0",
+			want: `containers:
+  - name: app
+    tag: v1.1.0
+`,
+			wantErr: false,
+		},
+		{
+			name:    "already updated",
+			content: "image:\n  tag: v1.1.0\n",
+			key:     "image.tag",
+			tag:     "v1.1.0",
+			want:    "image:\n  tag: v1.1.0\n",
+			wantErr: false,
+		},
+		{
+			name:    "quote tag read as a number",
+			content: "image:\n  tag: 1.9\n",
+			key:     "image.tag",
+			tag:     "1.10",
+			want:    "image:\n  tag: \"1.10\"\n",
+			wantErr: false,
+		},
+		{
+			name:    "key not found",
+			content: "image:\n  repository: ghcr.io/p1ass/mikku\n",
+			key:     "image.tag",
+			wantErr: true,
+		},
+		{
+			name:    "not scalar",
+			content: "image:\n  tag:\n    name: v1.0.0\n",
+			key:     "image.tag",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := updateHelmValue(tt.content, tt.key, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("updateHelmValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("updateHelmValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_updateChartAppVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "without v prefix",
+			content: "apiVersion: v2\nname: mikku\nversion: 0.1.0\nappVersion: \"1.0.0\"\n",
+			want:    "apiVersion: v2\nname: mikku\nversion: 0.1.0\nappVersion: \"1.1.0\"\n",
+			wantErr: false,
+		},
+		{
+			name:    "with v prefix",
+			content: "name: mikku\nappVersion: v1.0.0\n",
+			want:    "name: mikku\nappVersion: v1.1.0\n",
+			wantErr: false,
+		},
+		{
+			name:    "appVersion not found",
+			content: "name: mikku\nversion: 0.1.0\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := updateChartAppVersion(tt.content, "v1.1.0")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("updateChartAppVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("updateChartAppVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_helmImageVariant(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		key     string
+		want    string
+	}{
+		{
+			name:    "same image",
+			content: "image:\n  repository: ghcr.io/p1ass/mikku\n  tag: v1.0.0\n",
+			key:     "image.tag",
+			want:    "",
+		},
+		{
+			name:    "another registry host",
+			content: "image:\n  repository: docker.io/p1ass/mikku\n  tag: v1.0.0\n",
+			key:     "image.tag",
+			want:    "docker.io/p1ass/mikku",
+		},
+		{
+			name:    "registry in another key",
+			content: "image:\n  registry: quay.io\n  repository: p1ass/mikku\n  tag: v1.0.0\n",
+			key:     "image.tag",
+			want:    "quay.io/p1ass/mikku",
+		},
+		{
+			name:    "top-level tag",
+			content: "repository: docker.io/p1ass/mikku\ntag: v1.0.0\n",
+			key:     "tag",
+			want:    "docker.io/p1ass/mikku",
+		},
+		{
+			name:    "another repository",
+			content: "image:\n  repository: docker.io/p1ass/proxy\n  tag: v1.0.0\n",
+			key:     "image.tag",
+			want:    "",
+		},
+		{
+			name:    "no repository",
+			content: "image:\n  tag: v1.0.0\n",
+			key:     "image.tag",
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := helmImageVariant(tt.content, tt.key, "ghcr.io/p1ass/mikku"); got != tt.want {
+				t.Errorf("helmImageVariant() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errKustomizeImageNotFound = errors.New("image not found in images of kustomization")
+
+// kustomizationFileNames are the file names kustomize reads in the order of priority
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// findKustomization returns the path and the content of the kustomization file in the overlay directory
+func findKustomization(ctx context.Context, read readFileFunc, overlay string) (string, string, error) {
+	for _, name := range kustomizationFileNames {
+		p := path.Join(overlay, name)
+		content, err := read(ctx, p)
+		if err != nil {
+			if errors.Is(err, errFileNotFound) {
+				continue
+			}
+			return "", "", err
+		}
+		return p, content, nil
+	}
+	return "", "", fmt.Errorf("%s: kustomization: %w", overlay, errFileNotFound)
+}
+
+// updateKustomizeImage sets newTag of the image in `images:` of the kustomization
+// The entry is matched by name or newName. If it is missing, it is appended to `images:`.
+// The rest of the file, including comments and key order, is kept as it is.
+func updateKustomizeImage(content, image, tag string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return "", fmt.Errorf("parse yaml: %w", err)
+	}
+
+	newEntry := []string{"- name: " + yamlScalarToken(image), "  newTag: " + yamlScalarToken(tag)}
+
+	// Empty file
+	if len(doc.Content) == 0 {
+		return string(insertYAMLLines([]byte(content), strings.Count(content, "\n")+1, append([]string{"images:"}, newEntry...))), nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return "", errors.New("kustomization should be a mapping")
+	}
+
+	images, err := lookupYAMLChild(root, "images")
+	if err != nil {
+		return string(insertYAMLLines([]byte(content), strings.Count(content, "\n")+1, append([]string{"images:"}, newEntry...))), nil
+	}
+	// `images:` without entries
+	if images.Kind == yaml.ScalarNode && images.Tag == "!!null" {
+		return string(insertYAMLLines([]byte(content), images.Line, newEntry)), nil
+	}
+	if images.Kind != yaml.SequenceNode || images.Style&yaml.FlowStyle != 0 {
+		return "", errors.New("images should be a block sequence")
+	}
+
+	for _, entry := range images.Content {
+		if !kustomizeImageMatches(entry, image) {
+			continue
+		}
+
+		if newTag, err := lookupYAMLChild(entry, "newTag"); err == nil {
+			if newTag.Value == tag {
+				return content, nil
+			}
+			updated, err := replaceYAMLString([]byte(content), newTag, tag)
+			if err != nil {
+				return "", fmt.Errorf("replace newTag: %w", err)
+			}
+			return string(updated), nil
+		}
+
+		// Add newTag with the same indent as the other keys of the entry
+		indent := strings.Repeat(" ", entry.Column-1)
+		return string(insertYAMLLines([]byte(content), yamlLastLine(entry), []string{indent + "newTag: " + yamlScalarToken(tag)})), nil
+	}
+
+	// Append the entry with the same indent as the other entries
+	indent := ""
+	if len(images.Content) > 0 {
+		indent = strings.Repeat(" ", images.Content[0].Column-3)
+	}
+	lines := make([]string, 0, len(newEntry))
+	for _, l := range newEntry {
+		lines = append(lines, indent+l)
+	}
+	return string(insertYAMLLines([]byte(content), yamlLastLine(images), lines)), nil
+}
+
+// setKustomizeImageDigest sets digest of the image in `images:` of the kustomization
+// kustomize prefers digest to newTag, so the digest is removed if it is empty to use newTag.
+func setKustomizeImageDigest(content, image, digest string) (string, error) {
+	entry, err := findKustomizeImage(content, image)
+	if err != nil {
+		if errors.Is(err, errKustomizeImageNotFound) && digest == "" {
+			return content, nil
+		}
+		return "", err
+	}
+
+	current, err := lookupYAMLChild(entry, "digest")
+	if err != nil {
+		if digest == "" {
+			return content, nil
+		}
+		indent := strings.Repeat(" ", entry.Column-1)
+		return string(insertYAMLLines([]byte(content), yamlLastLine(entry), []string{indent + "digest: " + yamlScalarToken(digest)})), nil
+	}
+
+	switch {
+	case current.Value == digest:
+		return content, nil
+	case digest == "":
+		return string(removeYAMLLine([]byte(content), current.Line)), nil
+	default:
+		updated, err := replaceYAMLString([]byte(content), current, digest)
+		if err != nil {
+			return "", fmt.Errorf("replace digest: %w", err)
+		}
+		return string(updated), nil
+	}
+}
+
+// kustomizeImageTag returns newTag and digest of the image in `images:` of the kustomization
+// The digest is empty if the image is not pinned.
+func kustomizeImageTag(content, image string) (string, string, error) {
+	entry, err := findKustomizeImage(content, image)
+	if err != nil {
+		if errors.Is(err, errKustomizeImageNotFound) {
+			return "", "", fmt.Errorf("%s: %w", image, errDeployedTagNotFound)
+		}
+		return "", "", err
+	}
+	newTag, err := lookupYAMLChild(entry, "newTag")
+	if err != nil || newTag.Value == "" {
+		return "", "", fmt.Errorf("%s: %w", image, errDeployedTagNotFound)
+	}
+	digest := ""
+	if d, err := lookupYAMLChild(entry, "digest"); err == nil {
+		digest = d.Value
+	}
+	return newTag.Value, digest, nil
+}
+
+// findKustomizeImage returns the entry of the image in `images:` of the kustomization
+func findKustomizeImage(content, image string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: %w", image, errKustomizeImageNotFound)
+	}
+
+	images, err := lookupYAMLChild(doc.Content[0], "images")
+	if err != nil || images.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: %w", image, errKustomizeImageNotFound)
+	}
+	for _, entry := range images.Content {
+		if kustomizeImageMatches(entry, image) {
+			return entry, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", image, errKustomizeImageNotFound)
+}
+
+// kustomizeImageMatches reports whether the entry of `images:` is for the image
+func kustomizeImageMatches(entry *yaml.Node, image string) bool {
+	for _, key := range []string{"name", "newName"} {
+		if n, err := lookupYAMLChild(entry, key); err == nil && n.Value == image {
+			return true
+		}
+	}
+	return false
+}
+
+// kustomizeImageVariants returns the images in `images:` which have the same repository as the image but don't match it
+// Ex. docker.io/p1ass/api for ghcr.io/p1ass/api. They are left as they are, so they are reported to the user.
+func kustomizeImageVariants(content, image string) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	images, err := lookupYAMLChild(doc.Content[0], "images")
+	if err != nil || images.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+
+	_, repo := parseImageReference(image)
+	var variants []string
+	for _, entry := range images.Content {
+		if kustomizeImageMatches(entry, image) {
+			continue
+		}
+		for _, key := range []string{"name", "newName"} {
+			n, err := lookupYAMLChild(entry, key)
+			if err != nil {
+				continue
+			}
+			if _, r := parseImageReference(n.Value); r == repo {
+				variants = append(variants, n.Value)
+				break
+			}
+		}
+	}
+	return variants, nil
+}
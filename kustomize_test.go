@@ -0,0 +1,271 @@
+package mikku
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_updateKustomizeImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "update newTag in place",
+			content: `# production overlay
+resources:
+  - ../../base
+images:
+  - name: ghcr.io/p1ass/mikku # app
+    newTag: v1.0.0 # updated by mikku
+  - name: redis
+    newTag: "6.0"
+`,
+			tag: "v1.1.0",
+			want: `# production overlay
+resources:
+  - ../../base
+images:
+  - name: ghcr.io/p1ass/mikku # app
+    newTag: v1.1.0 # updated by mikku
+  - name: redis
+    newTag: "6.0"
+`,
+			wantErr: false,
+		},
+		{
+			name: "match newName",
+			content: `images:
+- name: app
+  newName: ghcr.io/p1ass/mikku
+  newTag: 'v1.0.0'
+`,
+			tag: "v1.1.0",
+			want: `images:
+- name: app
+  newName: ghcr.io/p1ass/mikku
+  newTag: 'v1.1.0'
+`,
+			wantErr: false,
+		},
+		{
+			name: "add newTag to entry",
+			content: `images:
+  - name: ghcr.io/p1ass/mikku
+    digest: sha256:abc
+namespace: prod
+`,
+			tag: "v1.1.0",
+			want: `images:
+  - name: ghcr.io/p1ass/mikku
+    digest: sha256:abc
+    newTag: v1.1.0
+namespace: prod
+`,
+			wantErr: false,
+		},
+		{
+			name: "append entry",
+			content: `images:
+  - name: redis
+    newTag: "6.0"
+namespace: prod
+`,
+			tag: "v1.1.0",
+			want: `images:
+  - name: redis
+    newTag: "6.0"
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+namespace: prod
+`,
+			wantErr: false,
+		},
+		{
+			name: "add images",
+			content: `resources:
+- ../../base`,
+			tag: "v1.1.0",
+			want: `resources:
+- ../../base
+images:
+- name: ghcr.io/p1ass/mikku
+  newTag: v1.1.0
+`,
+			wantErr: false,
+		},
+		{
+			name: "empty images",
+			content: `images:
+namespace: prod
+`,
+			tag: "v1.1.0",
+			want: `images:
+- name: ghcr.io/p1ass/mikku
+  newTag: v1.1.0
+namespace: prod
+`,
+			wantErr: false,
+		},
+		{
+			name:    "quote tag read as a number",
+			content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: 1.9\n",
+			tag:     "1.10",
+			want:    "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: \"1.10\"\n",
+			wantErr: false,
+		},
+		{
+			name:    "flow style images",
+			content: "images: [{name: ghcr.io/p1ass/mikku, newTag: v1.0.0}]\n",
+			tag:     "v1.1.0",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateKustomizeImage(tt.content, "ghcr.io/p1ass/mikku", tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("updateKustomizeImage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("updateKustomizeImage() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_setKustomizeImageDigest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		digest  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "add digest",
+			content: `images:
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+namespace: prod
+`,
+			digest: "sha256:abc",
+			want: `images:
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+    digest: sha256:abc
+namespace: prod
+`,
+			wantErr: false,
+		},
+		{
+			name: "replace digest",
+			content: `images:
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+    digest: sha256:old # pinned
+`,
+			digest: "sha256:abc",
+			want: `images:
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+    digest: sha256:abc # pinned
+`,
+			wantErr: false,
+		},
+		{
+			name: "remove stale digest",
+			content: `images:
+  - name: ghcr.io/p1ass/mikku
+    digest: sha256:old
+    newTag: v1.1.0
+namespace: prod
+`,
+			digest: "",
+			want: `images:
+  - name: ghcr.io/p1ass/mikku
+    newTag: v1.1.0
+namespace: prod
+`,
+			wantErr: false,
+		},
+		{
+			name:    "not pinned",
+			content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n",
+			digest:  "",
+			want:    "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n",
+			wantErr: false,
+		},
+		{
+			name:    "image not found",
+			content: "images:\n- name: redis\n  newTag: \"6.0\"\n",
+			digest:  "sha256:abc",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setKustomizeImageDigest(tt.content, "ghcr.io/p1ass/mikku", tt.digest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("setKustomizeImageDigest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("setKustomizeImageDigest() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_kustomizeImageVariants(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "image on another registry host",
+			content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n- name: docker.io/p1ass/mikku\n  newTag: v0.9.0\n- name: redis\n",
+			want:    []string{"docker.io/p1ass/mikku"},
+		},
+		{
+			name:    "renamed by newName",
+			content: "images:\n- name: mikku\n  newName: registry.example.com/p1ass/mikku\n",
+			want:    []string{"registry.example.com/p1ass/mikku"},
+		},
+		{
+			name:    "matched image",
+			content: "images:\n- name: mikku\n  newName: ghcr.io/p1ass/mikku\n",
+			want:    nil,
+		},
+		{
+			name:    "no images",
+			content: "namespace: prod\n",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kustomizeImageVariants(tt.content, "ghcr.io/p1ass/mikku")
+			if err != nil {
+				t.Fatalf("kustomizeImageVariants() error = %v", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("kustomizeImageVariants() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,616 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var (
+	errManifestNotConfigured = errors.New("manifest is not configured")
+	errNoManifestChanges     = errors.New("manifests already use the tag")
+)
+
+// defaultManifestEnvironment is used in the branch name of the top-level manifests
+const defaultManifestEnvironment = "default"
+
+// readFileFunc reads the file of the manifest repository
+// It returns errFileNotFound if the file doesn't exist.
+type readFileFunc func(ctx context.Context, path string) (string, error)
+
+// commitFunc commits the changes on top of the base branch and force-updates the branch of the manifest repository
+type commitFunc func(ctx context.Context, branch, message string, changes []*fileChange) error
+
+// manifestRepository reads and commits the files of the manifest repository
+// The files are accessed via GitHub API or a local git working copy, and both share the same edits.
+type manifestRepository struct {
+	read   readFileFunc
+	commit commitFunc
+	// close removes the local working copy
+	close func() error
+}
+
+// openManifestRepository returns the local git working copy if manifest.git is set. Otherwise, GitHub API is used.
+func openManifestRepository(ctx context.Context, svc *githubClient, mc *ManifestConfig) (*manifestRepository, error) {
+	if mc.Git == nil {
+		return newGitHubManifestRepository(svc, mc.Repository), nil
+	}
+	local, err := openLocalRepository(ctx, mc.Git)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest repository: %w", err)
+	}
+	return &manifestRepository{read: local.readFile, commit: local.forceCommit, close: local.close}, nil
+}
+
+func newGitHubManifestRepository(svc *githubClient, repo string) *manifestRepository {
+	return &manifestRepository{
+		read: func(ctx context.Context, path string) (string, error) {
+			return svc.getFile(ctx, repo, path, baseBranch)
+		},
+		commit: func(ctx context.Context, branch, message string, changes []*fileChange) error {
+			_, err := svc.forceCommitFiles(ctx, repo, baseBranch, branch, message, changes)
+			return err
+		},
+		close: func() error { return nil },
+	}
+}
+
+// closeManifestRepository closes the manifest repository at the end of the command
+// The command has already done its work, so the error is printed instead of failing it. The leftover should be removed by hand.
+func closeManifestRepository(w io.Writer, manifests *manifestRepository) {
+	if err := manifests.close(); err != nil {
+		_, _ = fmt.Fprintf(w, "Failed to clean up the manifest repository: %v\n", err)
+	}
+}
+
+// PullRequestOptions represents optional settings of `mikku pr` command
+type PullRequestOptions struct {
+	// Tag is the image tag written to the manifests. If it is empty, the tag of the latest release is used.
+	Tag string
+	// Environment is the environment in manifest.environments of the config file. If it is empty, the top-level manifests are updated.
+	Environment string
+	// Overlays overrides the kustomize overlay directories in the config file
+	Overlays []string
+	// PinDigest resolves the digest of the tag from the registry and pins the image by it
+	PinDigest bool
+	// SkipImageCheck skips checking that the image of the tag exists in the registry
+	SkipImageCheck bool
+	// WaitForImage waits until the image is pushed instead of failing. It gives up after WaitTimeout.
+	WaitForImage bool
+	WaitTimeout  time.Duration
+	// DryRun prints the diff of the manifests and the skipped files instead of opening the pull request
+	DryRun bool
+	// Settings is merged into the pull request settings in the config file
+	Settings *PullRequestConfig
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+}
+
+// manifestUpdate represents the image tag written to the manifests
+type manifestUpdate struct {
+	repo  string
+	image string
+	tag   string
+	// digest pins the image if it is not empty. Ex. sha256:0123...
+	digest string
+	// releaseURL is the release of the tag. It is written in the pull request body.
+	releaseURL string
+}
+
+// PullRequest is the entry point of `mikku pr` command
+// It opens a pull request updating the image tag in the manifest repository.
+func PullRequest(ctx context.Context, repo string, opts PullRequestOptions) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+
+	mc := cfg.repository(repo).Manifest
+	if mc == nil {
+		return fmt.Errorf("pr: %s: %w", repo, errManifestNotConfigured)
+	}
+	targets := *mc
+	if opts.Environment != "" {
+		env, err := mc.environment(opts.Environment)
+		if err != nil {
+			return fmt.Errorf("pr: %w", err)
+		}
+		targets = *env
+	}
+	if len(opts.Overlays) > 0 {
+		targets.Kustomize = opts.Overlays
+	}
+	settings := targets.PullRequest.merge(opts.Settings)
+	if err := settings.validate(); err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	update, err := resolveManifestUpdate(ctx, svc, repo, mc.Image, opts.Tag)
+	if err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+
+	registry := newRegistryClient(cfg, opts.RequestTimeout)
+	if !opts.SkipImageCheck {
+		if err := checkImage(ctx, registry, mc.Image, update.tag, opts); err != nil {
+			return fmt.Errorf("pr: %w", err)
+		}
+	}
+	if opts.PinDigest {
+		update.digest, err = registry.resolveDigest(ctx, mc.Image, update.tag)
+		if err != nil {
+			return fmt.Errorf("pr: resolve digest: %w", err)
+		}
+	}
+
+	manifests, err := openManifestRepository(ctx, svc, mc)
+	if err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+	defer closeManifestRepository(os.Stderr, manifests)
+
+	plan, err := planManifestChanges(ctx, manifests.read, &targets, update)
+	if err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+	if opts.DryRun {
+		if err := printManifestPlan(os.Stdout, plan); err != nil {
+			return fmt.Errorf("pr: %w", err)
+		}
+	}
+	changes := plan.changes
+	if len(changes) == 0 {
+		if !opts.DryRun {
+			stale := &manifestPullRequest{app: repo, env: opts.Environment}
+			if err := closeStaleManifestPullRequests(ctx, svc, mc.Repository, stale, update.tag); err != nil {
+				return fmt.Errorf("pr: %w", err)
+			}
+		}
+		return fmt.Errorf("pr: %s: %w", update.tag, errNoManifestChanges)
+	}
+	if err := validateManifestChanges(ctx, manifests.read, &targets, changes); err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+
+	mpr := newManifestPullRequest(update, opts.Environment, changes)
+	mpr.settings = settings
+	if opts.DryRun {
+		_, _ = fmt.Fprintf(os.Stdout, "\nDry run: %q would be committed to %s.\n", mpr.title, mpr.branch())
+		return nil
+	}
+	if err := publishManifestChanges(ctx, svc, mc.Repository, manifests, mpr, changes); err != nil {
+		return fmt.Errorf("pr: %w", err)
+	}
+	return nil
+}
+
+// publishManifestChanges opens the pull request of the changes
+// If the manifest repository is not on GitHub, only the branch is pushed.
+func publishManifestChanges(ctx context.Context, svc *githubClient, manifestRepo string, manifests *manifestRepository, mpr *manifestPullRequest, changes []*fileChange) error {
+	if manifestRepo == "" {
+		if err := manifests.commit(ctx, mpr.branch(), mpr.title, changes); err != nil {
+			return fmt.Errorf("commit manifests: %w", err)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Branch %s was pushed.\n", mpr.branch())
+		return nil
+	}
+
+	pr, updated, err := openManifestPullRequest(ctx, svc, manifestRepo, manifests.commit, mpr, changes)
+	if err != nil {
+		return err
+	}
+	printManifestPullRequest(pr, updated)
+	return nil
+}
+
+// resolveManifestUpdate returns the tag of the latest release if tag is empty
+func resolveManifestUpdate(ctx context.Context, svc *githubClient, repo, image, tag string) (*manifestUpdate, error) {
+	update := &manifestUpdate{repo: repo, image: image, tag: tag}
+	if tag != "" {
+		return update, nil
+	}
+
+	latest, err := svc.getLatestRelease(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("get latest release: %w", err)
+	}
+	update.tag = latest.GetTagName()
+	update.releaseURL = latest.GetHTMLURL()
+	return update, nil
+}
+
+// checkImage checks that the image of the tag has been pushed to the registry
+// A manifest pull request for a missing image would make pods fail to pull it after merging.
+func checkImage(ctx context.Context, registry *registryClient, image, tag string, opts PullRequestOptions) error {
+	if opts.WaitForImage {
+		_, _ = fmt.Fprintf(os.Stdout, "Waiting for %s:%s to be pushed...\n", image, tag)
+		if err := registry.waitForImage(ctx, image, tag, opts.WaitTimeout); err != nil {
+			return fmt.Errorf("wait for image: %w", err)
+		}
+		return nil
+	}
+
+	exists, err := registry.imageExists(ctx, image, tag)
+	if err != nil {
+		return fmt.Errorf("check image: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%s:%s: %w", image, tag, errImageNotFound)
+	}
+	return nil
+}
+
+// manifestPlan represents the edits of the manifests
+type manifestPlan struct {
+	changes []*fileChange
+	// original is the content of the changed files before the edits
+	original map[string]string
+	skipped  []*skippedManifest
+}
+
+// skippedManifest represents a file which has the image but isn't updated
+type skippedManifest struct {
+	path   string
+	reason string
+}
+
+// planManifestChanges edits the manifests and records the files which are skipped
+// A file edited by several targets results in one change. Files which already use the tag are skipped.
+// The real run and `--dry-run` share it, so the preview is exactly what is committed.
+func planManifestChanges(ctx context.Context, read readFileFunc, mc *ManifestConfig, update *manifestUpdate) (*manifestPlan, error) {
+	var (
+		paths    []string
+		original = map[string]string{}
+		edited   = map[string]string{}
+		skipped  []*skippedManifest
+		// fetched is the files which are read but not edited yet
+		fetched = map[string]string{}
+	)
+	get := func(path string) (string, error) {
+		if c, ok := edited[path]; ok {
+			return c, nil
+		}
+		if c, ok := fetched[path]; ok {
+			return c, nil
+		}
+		c, err := read(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("get %s: %w", path, err)
+		}
+		fetched[path] = c
+		return c, nil
+	}
+	edit := func(path string, fn func(content string) (string, error)) error {
+		content, err := get(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := edited[path]; !ok {
+			original[path] = content
+			paths = append(paths, path)
+		}
+
+		updated, err := fn(content)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", path, err)
+		}
+		edited[path] = updated
+		return nil
+	}
+
+	for _, overlay := range mc.Kustomize {
+		path, content, err := findKustomization(ctx, read, overlay)
+		if err != nil {
+			return nil, fmt.Errorf("get kustomization: %w", err)
+		}
+		if _, ok := edited[path]; !ok {
+			original[path], edited[path] = content, content
+			paths = append(paths, path)
+		}
+		variants, err := kustomizeImageVariants(content, update.image)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for _, v := range variants {
+			skipped = append(skipped, &skippedManifest{path: path, reason: fmt.Sprintf("%s is on another registry host", v)})
+		}
+		if err := edit(path, func(content string) (string, error) {
+			updated, err := updateKustomizeImage(content, update.image, update.tag)
+			if err != nil {
+				return "", err
+			}
+			return setKustomizeImageDigest(updated, update.image, update.digest)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if mc.Helm != nil {
+		// variants is the number of the values which are skipped because they are for another registry host
+		variants := 0
+		for _, target := range mc.Helm.Values {
+			path, key, err := parseHelmValuesTarget(target)
+			if err != nil {
+				return nil, err
+			}
+			content, err := get(path)
+			if err != nil {
+				return nil, err
+			}
+			if v := helmImageVariant(content, key, update.image); v != "" {
+				skipped = append(skipped, &skippedManifest{path: target, reason: fmt.Sprintf("%s is on another registry host", v)})
+				variants++
+				continue
+			}
+			if err := edit(path, func(content string) (string, error) {
+				return updateHelmValue(content, key, update.pinnedTag())
+			}); err != nil {
+				return nil, err
+			}
+		}
+		// appVersion describes the image of the values, so it is left as it is if the values are for another registry host
+		if mc.Helm.Chart != "" && variants > 0 && variants == len(mc.Helm.Values) {
+			skipped = append(skipped, &skippedManifest{path: mc.Helm.Chart, reason: "the values are on another registry host"})
+		} else if mc.Helm.Chart != "" {
+			if err := edit(mc.Helm.Chart, func(content string) (string, error) {
+				return updateChartAppVersion(content, update.tag)
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	plan := &manifestPlan{original: map[string]string{}, skipped: skipped}
+	for _, path := range paths {
+		if edited[path] == original[path] {
+			plan.skipped = append(plan.skipped, &skippedManifest{path: path, reason: "already uses " + update.tag})
+			continue
+		}
+		plan.changes = append(plan.changes, &fileChange{path: path, content: edited[path]})
+		plan.original[path] = original[path]
+	}
+	return plan, nil
+}
+
+// printManifestPlan prints the unified diff of each changed file and the files which are skipped
+func printManifestPlan(w io.Writer, plan *manifestPlan) error {
+	for _, c := range plan.changes {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitDiffLines(plan.original[c.path]),
+			B:        splitDiffLines(c.content),
+			FromFile: "a/" + c.path,
+			ToFile:   "b/" + c.path,
+			Context:  3,
+		})
+		if err != nil {
+			return fmt.Errorf("diff %s: %w", c.path, err)
+		}
+		_, _ = fmt.Fprint(w, diff)
+	}
+
+	if len(plan.skipped) > 0 {
+		_, _ = fmt.Fprintf(w, "\nSkipped:\n")
+		for _, s := range plan.skipped {
+			_, _ = fmt.Fprintf(w, "- %s: %s\n", s.path, s.reason)
+		}
+	}
+	return nil
+}
+
+// splitDiffLines splits the content into lines keeping the newlines
+// difflib.SplitLines is not used because it adds an empty line after the last newline.
+func splitDiffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// manifestPullRequest represents the pull request which updates the manifests
+// There is only one open pull request for each app and environment, so it is updated by newer tags.
+type manifestPullRequest struct {
+	app string
+	// env is the environment in manifest.environments. It is empty for the top-level manifests.
+	env   string
+	title string
+	body  string
+	// settings is applied after the pull request is opened. It is optional.
+	settings *PullRequestConfig
+}
+
+func newManifestPullRequest(update *manifestUpdate, env string, changes []*fileChange) *manifestPullRequest {
+	title := fmt.Sprintf("Update %s to %s", update.repo, update.tag)
+	if env != "" {
+		title += " in " + env
+	}
+	return &manifestPullRequest{
+		app:   update.repo,
+		env:   env,
+		title: title,
+		body:  generateManifestPullRequestBody(update, changes),
+	}
+}
+
+// branch returns the deterministic branch of the app and the environment. Ex. mikku/api/prod
+func (mpr *manifestPullRequest) branch() string {
+	env := mpr.env
+	if env == "" {
+		env = defaultManifestEnvironment
+	}
+	return fmt.Sprintf("mikku/%s/%s", mpr.app, env)
+}
+
+// marker is a hidden comment in the body which identifies the pull requests of the app and the environment
+func (mpr *manifestPullRequest) marker() string {
+	return fmt.Sprintf("<!-- mikku:manifest app=%s env=%s -->", mpr.app, mpr.env)
+}
+
+// supersedes reports whether the open pull request on another branch is replaced by this one
+// Pull requests opened by older versions of mikku used a branch for each tag such as mikku/api-v1.0.0 or mikku/api-prod-v1.0.0.
+func (mpr *manifestPullRequest) supersedes(pr *github.PullRequest) bool {
+	if pr.GetHead().GetRef() == mpr.branch() {
+		return false
+	}
+	if strings.Contains(pr.GetBody(), mpr.marker()) {
+		return true
+	}
+
+	prefix := "mikku/" + mpr.app + "-"
+	if mpr.env != "" {
+		prefix += mpr.env + "-"
+	}
+	legacy := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `v?[0-9]+\.[0-9]+\.[0-9]+\S*$`)
+	return legacy.MatchString(pr.GetHead().GetRef())
+}
+
+// openManifestPullRequest commits the changes to the branch of the app and the environment and opens a pull request to the base branch
+// If the pull request is already open, the branch is force-updated and the title and the body are rewritten.
+// The other open pull requests of the app and the environment are closed.
+// It returns the URL of the pull request and whether the open pull request was updated.
+func openManifestPullRequest(ctx context.Context, svc *githubClient, manifestRepo string, commit commitFunc, mpr *manifestPullRequest, changes []*fileChange) (string, bool, error) {
+	branch := mpr.branch()
+
+	open, err := svc.listOpenPullRequests(ctx, manifestRepo, baseBranch)
+	if err != nil {
+		return "", false, fmt.Errorf("list manifest pull requests: %w", err)
+	}
+	var (
+		existing   *github.PullRequest
+		superseded []*github.PullRequest
+	)
+	for _, pr := range open {
+		if pr.GetHead().GetLabel() == svc.owner+":"+branch {
+			existing = pr
+			continue
+		}
+		if mpr.supersedes(pr) {
+			superseded = append(superseded, pr)
+		}
+	}
+
+	// The commit is always based on the base branch, so the pull request has a single commit of the latest tag
+	if err := commit(ctx, branch, mpr.title, changes); err != nil {
+		return "", false, fmt.Errorf("commit manifests: %w", err)
+	}
+
+	body := mpr.body + "\n" + mpr.marker() + "\n"
+	var pr *github.PullRequest
+	if existing != nil {
+		pr, err = svc.editPullRequest(ctx, manifestRepo, existing.GetNumber(), mpr.title, body)
+		if err != nil {
+			return "", false, fmt.Errorf("update manifest pull request (branch %s was updated): %w", branch, err)
+		}
+	} else {
+		// The branch is left if it fails, so report it to clean up
+		pr, err = svc.createPullRequest(ctx, manifestRepo, branch, baseBranch, mpr.title, body)
+		if err != nil {
+			return "", false, fmt.Errorf("create manifest pull request (branch %s was created): %w", branch, err)
+		}
+	}
+
+	if err := applyPullRequestSettings(ctx, svc, manifestRepo, pr, mpr.settings); err != nil {
+		return "", false, fmt.Errorf("configure manifest pull request (%s was opened): %w", pr.GetHTMLURL(), err)
+	}
+	for _, old := range superseded {
+		comment := fmt.Sprintf("Superseded by #%d.", pr.GetNumber())
+		if err := svc.closePullRequest(ctx, manifestRepo, old.GetNumber(), comment); err != nil {
+			return "", false, fmt.Errorf("close superseded pull request #%d (%s was opened): %w", old.GetNumber(), pr.GetHTMLURL(), err)
+		}
+	}
+	return pr.GetHTMLURL(), existing != nil, nil
+}
+
+// closeStaleManifestPullRequests closes the open pull requests of the app and the environment when the base branch already uses the tag
+// They are left from the earlier runs and would roll the manifests back if they were merged.
+func closeStaleManifestPullRequests(ctx context.Context, svc *githubClient, manifestRepo string, mpr *manifestPullRequest, tag string) error {
+	if manifestRepo == "" {
+		return nil
+	}
+	open, err := svc.listOpenPullRequests(ctx, manifestRepo, baseBranch)
+	if err != nil {
+		return fmt.Errorf("list manifest pull requests: %w", err)
+	}
+	for _, pr := range open {
+		if pr.GetHead().GetLabel() != svc.owner+":"+mpr.branch() && !mpr.supersedes(pr) {
+			continue
+		}
+		comment := fmt.Sprintf("Closed because %s already uses %s.", baseBranch, tag)
+		if err := svc.closePullRequest(ctx, manifestRepo, pr.GetNumber(), comment); err != nil {
+			return fmt.Errorf("close stale pull request #%d: %w", pr.GetNumber(), err)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Stale pull request %s was closed.\n", pr.GetHTMLURL())
+	}
+	return nil
+}
+
+// applyPullRequestSettings requests reviewers, adds labels and assignees and enables auto-merge
+func applyPullRequestSettings(ctx context.Context, svc *githubClient, repo string, pr *github.PullRequest, settings *PullRequestConfig) error {
+	if settings == nil {
+		return nil
+	}
+	if len(settings.Reviewers) > 0 || len(settings.TeamReviewers) > 0 {
+		if err := svc.requestReviewers(ctx, repo, pr.GetNumber(), settings.Reviewers, settings.TeamReviewers); err != nil {
+			return fmt.Errorf("request reviewers: %w", err)
+		}
+	}
+	if len(settings.Labels) > 0 {
+		if err := svc.addLabels(ctx, repo, pr.GetNumber(), settings.Labels); err != nil {
+			return fmt.Errorf("add labels: %w", err)
+		}
+	}
+	if len(settings.Assignees) > 0 {
+		if err := svc.addAssignees(ctx, repo, pr.GetNumber(), settings.Assignees); err != nil {
+			return fmt.Errorf("add assignees: %w", err)
+		}
+	}
+	if method := settings.autoMergeMethod(); method != "" {
+		if err := svc.enableAutoMerge(ctx, pr, method); err != nil {
+			return fmt.Errorf("enable auto-merge: %w", err)
+		}
+	}
+	return nil
+}
+
+// pinnedTag returns the tag with the digest such as `v1.0.0@sha256:0123...`
+// It is written to Helm values which are joined with the image name in templates.
+func (u *manifestUpdate) pinnedTag() string {
+	if u.digest == "" {
+		return u.tag
+	}
+	return u.tag + "@" + u.digest
+}
+
+func printManifestPullRequest(url string, updated bool) {
+	if updated {
+		_, _ = fmt.Fprintf(os.Stdout, "Pull request was updated.\n")
+	} else {
+		_, _ = fmt.Fprintf(os.Stdout, "Pull request was created.\n")
+	}
+	_, _ = fmt.Fprintf(os.Stdout, url+"\n")
+}
+
+func generateManifestPullRequestBody(update *manifestUpdate, changes []*fileChange) string {
+	body := fmt.Sprintf("Update `%s` to `%s`.\n\n", update.image, update.tag)
+	if update.digest != "" {
+		body += fmt.Sprintf("Digest: `%s`\n\n", update.digest)
+	}
+	if update.releaseURL != "" {
+		body += fmt.Sprintf("Release: %s\n\n", update.releaseURL)
+	}
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, "- `"+c.path+"`")
+	}
+	return body + strings.Join(lines, "\n") + "\n"
+}
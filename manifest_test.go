@@ -0,0 +1,548 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+// readFiles returns readFileFunc which reads the files from the map
+func readFiles(files map[string]string) readFileFunc {
+	return func(ctx context.Context, path string) (string, error) {
+		content, ok := files[path]
+		if !ok {
+			return "", fmt.Errorf("%s: %w", path, errFileNotFound)
+		}
+		return content, nil
+	}
+}
+
+func Test_planManifestChanges_targets(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"overlays/dev/kustomization.yaml":     "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n",
+		"overlays/staging/kustomization.yml":  "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n",
+		"overlays/prod/kustomization.yaml":    "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n",
+		"overlays/invalid/kustomization.yaml": "images: [\n",
+		"chart/values.yaml":                   "image:\n  tag: v1.0.0 # app\nsidecar:\n  tag: v1.0.0\n",
+		"chart/Chart.yaml":                    "name: mikku\nappVersion: \"1.0.0\"\n",
+	}
+	update := &manifestUpdate{repo: "mikku", image: "ghcr.io/p1ass/mikku", tag: "v1.1.0"}
+
+	tests := []struct {
+		name    string
+		mc      *ManifestConfig
+		want    []*fileChange
+		wantErr error
+	}{
+		{
+			name: "update overlays",
+			mc:   &ManifestConfig{Kustomize: []string{"overlays/dev", "overlays/staging", "overlays/prod"}},
+			want: []*fileChange{
+				{path: "overlays/dev/kustomization.yaml", content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n"},
+				{path: "overlays/staging/kustomization.yml", content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n"},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "update helm values and chart",
+			mc: &ManifestConfig{Helm: &HelmConfig{
+				Values: []string{"chart/values.yaml:image.tag", "chart/values.yaml:sidecar.tag"},
+				Chart:  "chart/Chart.yaml",
+			}},
+			want: []*fileChange{
+				{path: "chart/values.yaml", content: "image:\n  tag: v1.1.0 # app\nsidecar:\n  tag: v1.1.0\n"},
+				{path: "chart/Chart.yaml", content: "name: mikku\nappVersion: \"1.1.0\"\n"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "overlay not found",
+			mc:      &ManifestConfig{Kustomize: []string{"overlays/unknown"}},
+			want:    nil,
+			wantErr: errFileNotFound,
+		},
+		{
+			name:    "helm values not found",
+			mc:      &ManifestConfig{Helm: &HelmConfig{Values: []string{"chart/unknown.yaml:image.tag"}}},
+			want:    nil,
+			wantErr: errFileNotFound,
+		},
+		{
+			name:    "helm key not found",
+			mc:      &ManifestConfig{Helm: &HelmConfig{Values: []string{"chart/values.yaml:image.repository"}}},
+			want:    nil,
+			wantErr: errYAMLPathNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planManifestChanges(context.Background(), readFiles(files), tt.mc, update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("planManifestChanges() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var got []*fileChange
+			if plan != nil {
+				got = plan.changes
+			}
+			if !cmp.Equal(got, tt.want, cmp.AllowUnexported(fileChange{})) {
+				t.Errorf("planManifestChanges() diff=%s", cmp.Diff(got, tt.want, cmp.AllowUnexported(fileChange{})))
+			}
+		})
+	}
+
+	if _, err := planManifestChanges(context.Background(), readFiles(files), &ManifestConfig{Kustomize: []string{"overlays/invalid"}}, update); err == nil {
+		t.Errorf("planManifestChanges() error = nil, want parse error")
+	}
+}
+
+func Test_planManifestChanges_pinDigest(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"overlays/prod/kustomization.yaml": "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n",
+		"chart/values.yaml":                "image:\n  tag: v1.0.0\n",
+		"chart/Chart.yaml":                 "appVersion: v1.0.0\n",
+	}
+	mc := &ManifestConfig{
+		Kustomize: []string{"overlays/prod"},
+		Helm:      &HelmConfig{Values: []string{"chart/values.yaml:image.tag"}, Chart: "chart/Chart.yaml"},
+	}
+	update := &manifestUpdate{repo: "mikku", image: "ghcr.io/p1ass/mikku", tag: "v1.1.0", digest: "sha256:abc"}
+
+	want := []*fileChange{
+		{path: "overlays/prod/kustomization.yaml", content: "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n  digest: sha256:abc\n"},
+		{path: "chart/values.yaml", content: "image:\n  tag: v1.1.0@sha256:abc\n"},
+		{path: "chart/Chart.yaml", content: "appVersion: v1.1.0\n"},
+	}
+	plan, err := planManifestChanges(context.Background(), readFiles(files), mc, update)
+	if err != nil {
+		t.Fatalf("planManifestChanges() error = %v", err)
+	}
+	if !cmp.Equal(plan.changes, want, cmp.AllowUnexported(fileChange{})) {
+		t.Errorf("planManifestChanges() diff=%s", cmp.Diff(plan.changes, want, cmp.AllowUnexported(fileChange{})))
+	}
+}
+
+func Test_planManifestChanges(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"overlays/dev/kustomization.yaml":  "namespace: dev\nimages:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n- name: p1ass/mikku\n  newTag: v0.9.0\n",
+		"overlays/prod/kustomization.yaml": "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n",
+	}
+	mc := &ManifestConfig{Kustomize: []string{"overlays/dev", "overlays/prod"}}
+	update := &manifestUpdate{repo: "mikku", image: "ghcr.io/p1ass/mikku", tag: "v1.1.0"}
+
+	plan, err := planManifestChanges(context.Background(), readFiles(files), mc, update)
+	if err != nil {
+		t.Fatalf("planManifestChanges() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := printManifestPlan(&buf, plan); err != nil {
+		t.Fatalf("printManifestPlan() error = %v", err)
+	}
+	want := `--- a/overlays/dev/kustomization.yaml
++++ b/overlays/dev/kustomization.yaml
+@@ -1,6 +1,6 @@
+ namespace: dev
+ images:
+ - name: ghcr.io/p1ass/mikku
+-  newTag: v1.0.0
++  newTag: v1.1.0
+ - name: p1ass/mikku
+   newTag: v0.9.0
+
+Skipped:
+- overlays/dev/kustomization.yaml: p1ass/mikku is on another registry host
+- overlays/prod/kustomization.yaml: already uses v1.1.0
+`
+	if got := buf.String(); got != want {
+		t.Errorf("printManifestPlan() diff=%s", cmp.Diff(got, want))
+	}
+}
+
+func Test_planManifestChanges_helm(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"charts/app/values.yaml":    "image:\n  repository: ghcr.io/p1ass/mikku\n  tag: v1.0.0\nmirror:\n  repository: docker.io/p1ass/mikku\n  tag: v1.0.0\n",
+		"charts/app/Chart.yaml":     "name: app\nappVersion: v1.0.0\n",
+		"charts/mirror/values.yaml": "image:\n  registry: quay.io\n  repository: p1ass/mikku\n  tag: v1.0.0\n",
+		"charts/mirror/Chart.yaml":  "name: mirror\nappVersion: v1.0.0\n",
+	}
+	update := &manifestUpdate{repo: "mikku", image: "ghcr.io/p1ass/mikku", tag: "v1.1.0"}
+
+	tests := []struct {
+		name        string
+		helm        *HelmConfig
+		wantChanges []string
+		want        []*skippedManifest
+	}{
+		{
+			name: "skip the values on another registry host",
+			helm: &HelmConfig{
+				Values: []string{"charts/app/values.yaml:image.tag", "charts/app/values.yaml:mirror.tag"},
+				Chart:  "charts/app/Chart.yaml",
+			},
+			wantChanges: []string{"charts/app/values.yaml", "charts/app/Chart.yaml"},
+			want: []*skippedManifest{
+				{path: "charts/app/values.yaml:mirror.tag", reason: "docker.io/p1ass/mikku is on another registry host"},
+			},
+		},
+		{
+			name:        "skip Chart.yaml of the values on another registry host",
+			helm:        &HelmConfig{Values: []string{"charts/mirror/values.yaml:image.tag"}, Chart: "charts/mirror/Chart.yaml"},
+			wantChanges: nil,
+			want: []*skippedManifest{
+				{path: "charts/mirror/values.yaml:image.tag", reason: "quay.io/p1ass/mikku is on another registry host"},
+				{path: "charts/mirror/Chart.yaml", reason: "the values are on another registry host"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			plan, err := planManifestChanges(context.Background(), readFiles(files), &ManifestConfig{Helm: tt.helm}, update)
+			if err != nil {
+				t.Fatalf("planManifestChanges() error = %v", err)
+			}
+			var changes []string
+			for _, c := range plan.changes {
+				changes = append(changes, c.path)
+			}
+			if !cmp.Equal(changes, tt.wantChanges) {
+				t.Errorf("planManifestChanges() changes diff=%s", cmp.Diff(changes, tt.wantChanges))
+			}
+			if !cmp.Equal(plan.skipped, tt.want, cmp.AllowUnexported(skippedManifest{})) {
+				t.Errorf("planManifestChanges() skipped diff=%s", cmp.Diff(plan.skipped, tt.want, cmp.AllowUnexported(skippedManifest{})))
+			}
+		})
+	}
+}
+
+func Test_generateManifestPullRequestBody(t *testing.T) {
+	t.Parallel()
+
+	update := &manifestUpdate{
+		repo:       "mikku",
+		image:      "ghcr.io/p1ass/mikku",
+		tag:        "v1.1.0",
+		releaseURL: "https://github.com/p1ass/mikku/releases/tag/v1.1.0",
+	}
+	got := generateManifestPullRequestBody(update, []*fileChange{{path: "overlays/dev/kustomization.yaml"}})
+	want := "Update `ghcr.io/p1ass/mikku` to `v1.1.0`.\n\nRelease: https://github.com/p1ass/mikku/releases/tag/v1.1.0\n\n- `overlays/dev/kustomization.yaml`\n"
+	if got != want {
+		t.Errorf("generateManifestPullRequestBody() diff=%s", cmp.Diff(got, want))
+	}
+}
+
+func Test_applyPullRequestSettings(t *testing.T) {
+	t.Parallel()
+
+	pr := &github.PullRequest{Number: github.Int(3), NodeID: github.String("PR_node")}
+
+	tests := []struct {
+		name     string
+		settings *PullRequestConfig
+		injector func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient)
+		wantErr  bool
+	}{
+		{
+			name: "all settings",
+			settings: &PullRequestConfig{
+				Reviewers:     []string{"p1ass"},
+				TeamReviewers: []string{"sre"},
+				Labels:        []string{"deploy"},
+				Assignees:     []string{"p1ass"},
+				AutoMerge:     "squash",
+			},
+			injector: func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient) {
+				prCli.EXPECT().RequestReviewers(gomock.Any(), "test-owner", "manifests", 3, github.ReviewersRequest{
+					Reviewers:     []string{"p1ass"},
+					TeamReviewers: []string{"sre"},
+				}).Return(nil, nil, nil)
+				issuesCli.EXPECT().AddLabelsToIssue(gomock.Any(), "test-owner", "manifests", 3, []string{"deploy"}).Return(nil, nil, nil)
+				issuesCli.EXPECT().AddAssignees(gomock.Any(), "test-owner", "manifests", 3, []string{"p1ass"}).Return(nil, nil, nil)
+				graphqlCli.EXPECT().Mutate(gomock.Any(), enableAutoMergeMutation, map[string]interface{}{
+					"pullRequestId": "PR_node",
+					"mergeMethod":   "SQUASH",
+				}, gomock.Any()).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:     "only team reviewers",
+			settings: &PullRequestConfig{TeamReviewers: []string{"sre"}},
+			injector: func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient) {
+				prCli.EXPECT().RequestReviewers(gomock.Any(), "test-owner", "manifests", 3, github.ReviewersRequest{
+					TeamReviewers: []string{"sre"},
+				}).Return(nil, nil, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:     "no settings",
+			settings: &PullRequestConfig{},
+			injector: func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient) {
+			},
+			wantErr: false,
+		},
+		{
+			name:     "auto-merge disabled",
+			settings: &PullRequestConfig{AutoMerge: autoMergeDisabled},
+			injector: func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient) {
+			},
+			wantErr: false,
+		},
+		{
+			name:     "auto-merge is not allowed",
+			settings: &PullRequestConfig{AutoMerge: "merge"},
+			injector: func(prCli *MockgitHubPullRequestsClient, issuesCli *MockgitHubIssuesClient, graphqlCli *MockgitHubGraphQLClient) {
+				graphqlCli.EXPECT().Mutate(gomock.Any(), enableAutoMergeMutation, gomock.Any(), gomock.Any()).
+					Return(errors.New("auto merge is not allowed for this repository"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			prCli := NewMockgitHubPullRequestsClient(ctrl)
+			issuesCli := NewMockgitHubIssuesClient(ctrl)
+			graphqlCli := NewMockgitHubGraphQLClient(ctrl)
+			tt.injector(prCli, issuesCli, graphqlCli)
+
+			s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, graphql: graphqlCli, issues: issuesCli})
+
+			if err := applyPullRequestSettings(context.Background(), s, "manifests", pr, tt.settings); (err != nil) != tt.wantErr {
+				t.Errorf("applyPullRequestSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_manifestPullRequest_supersedes(t *testing.T) {
+	t.Parallel()
+
+	mpr := &manifestPullRequest{app: "api", env: "prod"}
+	marker := mpr.marker()
+
+	tests := []struct {
+		name string
+		pr   *github.PullRequest
+		want bool
+	}{
+		{
+			name: "same branch",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("mikku/api/prod")}, Body: github.String(marker)},
+			want: false,
+		},
+		{
+			name: "marker on another branch",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("deploy-api")}, Body: github.String("body\n" + marker + "\n")},
+			want: true,
+		},
+		{
+			name: "branch for each tag",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("mikku/api-prod-v1.0.0")}},
+			want: true,
+		},
+		{
+			name: "another environment",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("mikku/api-staging-v1.0.0")}, Body: github.String((&manifestPullRequest{app: "api", env: "staging"}).marker())},
+			want: false,
+		},
+		{
+			name: "another app with the same prefix",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("mikku/api-prod-gateway-v1.0.0")}},
+			want: false,
+		},
+		{
+			name: "pull request by human",
+			pr:   &github.PullRequest{Head: &github.PullRequestBranch{Ref: github.String("fix-prod")}, Body: github.String("Fix prod")},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := mpr.supersedes(tt.pr); got != tt.want {
+				t.Errorf("manifestPullRequest.supersedes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_openManifestPullRequest(t *testing.T) {
+	t.Parallel()
+
+	changes := []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "images: []\n"}}
+	mpr := &manifestPullRequest{app: "api", env: "prod", title: "Update api to v1.1.0 in prod", body: "body\n"}
+	wantBody := "body\n\n<!-- mikku:manifest app=api env=prod -->\n"
+
+	expectCommit := func(gitCli *MockgitHubGitClient) {
+		gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "manifests", "heads/"+baseBranch).
+			Return(&github.Reference{Object: &github.GitObject{SHA: github.String("base-sha")}}, nil, nil)
+		gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "manifests", "base-sha").
+			Return(&github.Commit{SHA: github.String("base-sha"), Tree: &github.Tree{SHA: github.String("base-tree-sha")}}, nil, nil)
+		gitCli.EXPECT().CreateTree(gomock.Any(), "test-owner", "manifests", "base-tree-sha", gomock.Any()).
+			Return(&github.Tree{SHA: github.String("tree-sha")}, nil, nil)
+		gitCli.EXPECT().CreateCommit(gomock.Any(), "test-owner", "manifests", &github.Commit{
+			Message: github.String("Update api to v1.1.0 in prod"),
+			Tree:    &github.Tree{SHA: github.String("tree-sha")},
+			Parents: []*github.Commit{{SHA: github.String("base-sha")}},
+		}).Return(&github.Commit{SHA: github.String("commit-sha")}, nil, nil)
+	}
+	branchRef := &github.Reference{
+		Ref:    github.String("refs/heads/mikku/api/prod"),
+		Object: &github.GitObject{SHA: github.String("commit-sha")},
+	}
+
+	tests := []struct {
+		name        string
+		injector    func(prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient, issuesCli *MockgitHubIssuesClient)
+		want        string
+		wantUpdated bool
+	}{
+		{
+			name: "open new pull request and close superseded one",
+			injector: func(prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient, issuesCli *MockgitHubIssuesClient) {
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "manifests", gomock.Any()).Return([]*github.PullRequest{
+					{Number: github.Int(1), Head: &github.PullRequestBranch{Label: github.String("test-owner:mikku/api-prod-v1.0.0"), Ref: github.String("mikku/api-prod-v1.0.0")}},
+					{Number: github.Int(2), Head: &github.PullRequestBranch{Label: github.String("test-owner:fix"), Ref: github.String("fix")}},
+				}, &github.Response{}, nil)
+				expectCommit(gitCli)
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "manifests", "heads/mikku/api/prod").
+					Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found"))
+				gitCli.EXPECT().CreateRef(gomock.Any(), "test-owner", "manifests", branchRef).Return(nil, nil, nil)
+				prCli.EXPECT().Create(gomock.Any(), "test-owner", "manifests", &github.NewPullRequest{
+					Title: github.String("Update api to v1.1.0 in prod"),
+					Head:  github.String("mikku/api/prod"),
+					Base:  github.String(baseBranch),
+					Body:  github.String(wantBody),
+				}).Return(&github.PullRequest{Number: github.Int(3), HTMLURL: github.String("https://github.com/test-owner/manifests/pull/3")}, nil, nil)
+				issuesCli.EXPECT().CreateComment(gomock.Any(), "test-owner", "manifests", 1, &github.IssueComment{Body: github.String("Superseded by #3.")}).
+					Return(nil, nil, nil)
+				prCli.EXPECT().Edit(gomock.Any(), "test-owner", "manifests", 1, &github.PullRequest{State: github.String("closed")}).
+					Return(nil, nil, nil)
+			},
+			want:        "https://github.com/test-owner/manifests/pull/3",
+			wantUpdated: false,
+		},
+		{
+			name: "update open pull request",
+			injector: func(prCli *MockgitHubPullRequestsClient, gitCli *MockgitHubGitClient, issuesCli *MockgitHubIssuesClient) {
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "manifests", gomock.Any()).Return([]*github.PullRequest{
+					{Number: github.Int(4), Head: &github.PullRequestBranch{Label: github.String("test-owner:mikku/api/prod"), Ref: github.String("mikku/api/prod")}},
+				}, &github.Response{}, nil)
+				expectCommit(gitCli)
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "manifests", "heads/mikku/api/prod").
+					Return(&github.Reference{Object: &github.GitObject{SHA: github.String("old-sha")}}, nil, nil)
+				gitCli.EXPECT().UpdateRef(gomock.Any(), "test-owner", "manifests", branchRef, true).Return(nil, nil, nil)
+				prCli.EXPECT().Edit(gomock.Any(), "test-owner", "manifests", 4, &github.PullRequest{
+					Title: github.String("Update api to v1.1.0 in prod"),
+					Body:  github.String(wantBody),
+				}).Return(&github.PullRequest{Number: github.Int(4), HTMLURL: github.String("https://github.com/test-owner/manifests/pull/4")}, nil, nil)
+			},
+			want:        "https://github.com/test-owner/manifests/pull/4",
+			wantUpdated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			prCli := NewMockgitHubPullRequestsClient(ctrl)
+			gitCli := NewMockgitHubGitClient(ctrl)
+			issuesCli := NewMockgitHubIssuesClient(ctrl)
+			tt.injector(prCli, gitCli, issuesCli)
+
+			s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, git: gitCli, issues: issuesCli})
+
+			got, updated, err := openManifestPullRequest(context.Background(), s, "manifests", newGitHubManifestRepository(s, "manifests").commit, mpr, changes)
+			if err != nil {
+				t.Fatalf("openManifestPullRequest() error = %v", err)
+			}
+			if got != tt.want || updated != tt.wantUpdated {
+				t.Errorf("openManifestPullRequest() = (%s, %v), want (%s, %v)", got, updated, tt.want, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func Test_closeStaleManifestPullRequests(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	prCli := NewMockgitHubPullRequestsClient(ctrl)
+	issuesCli := NewMockgitHubIssuesClient(ctrl)
+
+	prCli.EXPECT().List(gomock.Any(), "test-owner", "manifests", gomock.Any()).Return([]*github.PullRequest{
+		{Number: github.Int(1), Head: &github.PullRequestBranch{Label: github.String("test-owner:mikku/api/prod"), Ref: github.String("mikku/api/prod")}},
+		{Number: github.Int(2), Head: &github.PullRequestBranch{Label: github.String("test-owner:mikku/api-prod-v1.0.0"), Ref: github.String("mikku/api-prod-v1.0.0")}},
+		{Number: github.Int(3), Head: &github.PullRequestBranch{Label: github.String("test-owner:mikku/api/staging"), Ref: github.String("mikku/api/staging")}},
+	}, &github.Response{}, nil)
+	for _, number := range []int{1, 2} {
+		issuesCli.EXPECT().CreateComment(gomock.Any(), "test-owner", "manifests", number, &github.IssueComment{Body: github.String("Closed because " + baseBranch + " already uses v1.1.0.")}).
+			Return(nil, nil, nil)
+		prCli.EXPECT().Edit(gomock.Any(), "test-owner", "manifests", number, &github.PullRequest{State: github.String("closed")}).
+			Return(nil, nil, nil)
+	}
+
+	s := newGitHubClient("test-owner", gitHubClients{pullRequests: prCli, issues: issuesCli})
+	mpr := &manifestPullRequest{app: "api", env: "prod"}
+	if err := closeStaleManifestPullRequests(context.Background(), s, "manifests", mpr, "v1.1.0"); err != nil {
+		t.Fatalf("closeStaleManifestPullRequests() error = %v", err)
+	}
+	// The branch is only pushed if the manifest repository is not on GitHub, so there are no pull requests to close
+	if err := closeStaleManifestPullRequests(context.Background(), s, "", mpr, "v1.1.0"); err != nil {
+		t.Fatalf("closeStaleManifestPullRequests() error = %v", err)
+	}
+}
+
+func Test_closeManifestRepository(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		close func() error
+		want  string
+	}{
+		{
+			name:  "closed",
+			close: func() error { return nil },
+			want:  "",
+		},
+		{
+			name:  "failed to remove worktree",
+			close: func() error { return errors.New("remove worktree /tmp/mikku-1/worktree: exit status 128") },
+			want:  "Failed to clean up the manifest repository: remove worktree /tmp/mikku-1/worktree: exit status 128\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			closeManifestRepository(&buf, &manifestRepository{close: tt.close})
+			if got := buf.String(); got != tt.want {
+				t.Errorf("closeManifestRepository() printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
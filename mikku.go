@@ -1,62 +1,266 @@
 package mikku
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/mod/semver"
 )
 
 var (
 	errInvalidSemanticVersioningTag = errors.New("invalid semantic versioning tag")
+	// errTagConflict represents error that the new tag already exists but points to another commit
+	errTagConflict = errors.New("tag already exists on another commit")
 )
 
+// ReleaseOptions represents optional settings of `mikku release` command
+type ReleaseOptions struct {
+	// ChangelogFile is the path of the changelog file committed before tagging
+	// If it is empty, the changelog file is not updated.
+	ChangelogFile string
+	// NotesSource is the source of the release body. github, mikku or both
+	NotesSource string
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+	// Interactive asks the user to choose the version, edit the body and confirm the release
+	// The bump type is not required.
+	Interactive bool
+}
+
 // Release is the entry point of `mikku release` command
-func Release(repo string, bumpTyp string) error {
+// Cancelling ctx aborts the release. Changes already pushed to GitHub are reported in the returned error.
+func Release(ctx context.Context, repo string, bumpTyp string, opts ReleaseOptions) error {
+	if err := validateNotesSource(opts.NotesSource); err != nil {
+		return fmt.Errorf("release: %w", err)
+	}
+
 	cfg, err := readConfig()
 	if err != nil {
 		return fmt.Errorf("release: %w", err)
 	}
 
-	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken)
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	var wiz *releaseWizard
+	if opts.Interactive {
+		wiz = newReleaseWizard()
+	}
 
+	newRelease, err := release(ctx, cfg, svc, os.Stdout, wiz, repo, bumpTyp, opts)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, newRelease.GetHTMLURL()+"\n")
+
+	return nil
+}
+
+// release creates a new release of the repository
+// Progress messages are written to w. If wiz is not nil, the user chooses the version instead of bumpTyp.
+func release(ctx context.Context, cfg *Config, svc *githubClient, w io.Writer, wiz *releaseWizard, repo string, bumpTyp string, opts ReleaseOptions) (*github.RepositoryRelease, error) {
 	isFirstRelease := false
 
-	after, currentTag, err := svc.getLastPublishedAndCurrentTag(repo)
+	after, currentTag, err := svc.getLastPublishedAndCurrentTag(ctx, repo)
 	if err != nil {
 		if errors.Is(err, errReleaseNotFound) {
 			isFirstRelease = true
-			_, _ = fmt.Fprintf(os.Stdout, "Release not found. First Release...\n")
+			_, _ = fmt.Fprintf(w, "Release not found. First Release...\n")
 
 		} else {
-			return fmt.Errorf("failed to get latest published date or tag: %w", err)
+			return nil, fmt.Errorf("failed to get latest published date or tag: %w", err)
+		}
+	}
+
+	// If the response of creating the release was lost, a retry sees the new release as the latest one.
+	// Bumping it again would publish a duplicate release, so the latest release is returned if the head is mikku's commit for it.
+	if !isFirstRelease && wiz == nil && strToBumpType(bumpTyp) != version {
+		latest, err := findRetriedRelease(ctx, svc, repo, currentTag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing release: %w", err)
+		}
+		if latest != nil {
+			_, _ = fmt.Fprintf(w, "Release %s was already created for the head of %s.\n", currentTag, baseBranch)
+			return latest, nil
+		}
+	}
+
+	var prs []*github.PullRequest
+	if wiz != nil {
+		prs, err = svc.getMergedPRsAfter(ctx, repo, after)
+		if err != nil {
+			return nil, fmt.Errorf("get pull requests: %w", err)
+		}
+		prs = excludeReleasePullRequests(prs)
+		bumpTyp, err = wiz.chooseVersion(currentTag, prs, func(tag string) (bool, error) {
+			return svc.tagExists(ctx, repo, tag)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to choose version: %w", err)
 		}
 	}
 
 	newTag, err := determineNewTag(currentTag, bumpTyp)
 	if err != nil {
 		if errors.Is(err, errInvalidSemanticVersioningTag) && isFirstRelease {
-			return fmt.Errorf("you must specify the tag because of the first release")
+			return nil, fmt.Errorf("you must specify the tag because of the first release")
 		}
-		return fmt.Errorf("failed to determine new tag: %w", err)
+		return nil, fmt.Errorf("failed to determine new tag: %w", err)
 	}
 
-	prs, err := svc.getMergedPRsAfter(repo, after)
+	// The release may have been created by the previous run which failed after calling the API
+	existing, err := findExistingRelease(ctx, svc, repo, newTag, "")
 	if err != nil {
-		return fmt.Errorf("get pull requests: %w", err)
+		return nil, fmt.Errorf("failed to check existing release: %w", err)
+	}
+	if existing != nil {
+		_, _ = fmt.Fprintf(w, "Release %s already exists.\n", newTag)
+		return existing, nil
 	}
 
-	body, err := generateReleaseBody(prs)
+	if wiz == nil {
+		prs, err = svc.getMergedPRsAfter(ctx, repo, after)
+		if err != nil {
+			return nil, fmt.Errorf("get pull requests: %w", err)
+		}
+		prs = excludeReleasePullRequests(prs)
+	}
+
+	repoCfg := cfg.repository(repo)
+	prs, dependencyUpdates := filterPRs(prs, repoCfg.Changelog)
+
+	contributors, err := collectContributors(ctx, svc, repo, prs, after, isFirstRelease)
 	if err != nil {
-		return fmt.Errorf("failed to generate release body: %w", err)
+		return nil, fmt.Errorf("failed to collect contributors: %w", err)
 	}
 
-	newRelease, err := svc.createRelease(repo, newTag, body)
+	note := &releaseNote{
+		PullRequests:      prs,
+		DependencyUpdates: dependencyUpdates,
+		Contributors:      contributors,
+	}
+
+	changes, err := generateVersionFileChanges(ctx, svc, repo, repoCfg.VersionFiles, newTag)
 	if err != nil {
-		return fmt.Errorf("failed to create release: %w", err)
+		return nil, fmt.Errorf("failed to bump version files: %w", err)
 	}
 
-	_, _ = fmt.Fprintf(os.Stdout, "Release was created.\n")
-	_, _ = fmt.Fprintf(os.Stdout, *newRelease.HTMLURL+"\n")
+	if opts.ChangelogFile != "" {
+		change, err := generateChangelogChange(ctx, svc, repo, opts.ChangelogFile, newTag, time.Now(), note)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update changelog: %w", err)
+		}
+		if change != nil {
+			changes = append(changes, change)
+		}
+	}
 
-	return nil
+	// Generate the body before pushing any changes so that failures don't leave a half-created release
+	body, err := generateNotes(ctx, svc, repo, opts.NotesSource, newTag, currentTag, "", note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate release body: %w", err)
+	}
+
+	prerelease := false
+	if wiz != nil {
+		body, err = wiz.editBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to edit release body: %w", err)
+		}
+		ok, err := wiz.confirmRelease(repo, newTag, body, changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to confirm release: %w", err)
+		}
+		if !ok {
+			return nil, errReleaseAborted
+		}
+		prerelease = semver.Prerelease(newTag) != ""
+	}
+
+	target := ""
+	if len(changes) > 0 {
+		target, err = commitReleaseFiles(ctx, svc, repo, newTag, changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit release files: %w", err)
+		}
+	}
+
+	newRelease, err := svc.createRelease(ctx, repo, newTag, target, body, prerelease)
+	if err != nil {
+		// The release may have been created even if the response was lost
+		if existing, findErr := findExistingRelease(ctx, svc, repo, newTag, target); findErr == nil && existing != nil {
+			_, _ = fmt.Fprintf(w, "Release was created.\n")
+			return existing, nil
+		}
+		if target != "" {
+			return nil, fmt.Errorf("failed to create release (commit %s was already pushed to %s): %w", target, baseBranch, err)
+		}
+		return nil, fmt.Errorf("failed to create release: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(w, "Release was created.\n")
+	return newRelease, nil
+}
+
+// findExistingRelease returns the release of the tag if the tag points to the target
+// If target is empty, the head of the base branch is the target.
+// It returns nil if the release doesn't exist, and errTagConflict if the tag points to another commit.
+func findExistingRelease(ctx context.Context, svc *githubClient, repo, tag, target string) (*github.RepositoryRelease, error) {
+	tagSHA, err := svc.getTagCommitSHA(ctx, repo, tag)
+	if err != nil {
+		if errors.Is(err, errTagNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+
+	if target == "" {
+		target, err = svc.getBranchSHA(ctx, repo, baseBranch)
+		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", baseBranch, err)
+		}
+	}
+	if tagSHA != target {
+		return nil, fmt.Errorf("%s points to %s, but the release target is %s: %w", tag, tagSHA, target, errTagConflict)
+	}
+
+	// The tag without a release is reused by creating the release
+	rel, err := svc.getReleaseByTag(ctx, repo, tag)
+	if err != nil {
+		if errors.Is(err, errReleaseNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get release: %w", err)
+	}
+	return rel, nil
+}
+
+// findRetriedRelease returns the release of the tag if the head of the base branch is the commit mikku made for it
+// It is the evidence of a retry. A release on another head commit is not returned, so releasing again on purpose isn't skipped.
+func findRetriedRelease(ctx context.Context, svc *githubClient, repo, tag string) (*github.RepositoryRelease, error) {
+	head, err := svc.getBranchSHA(ctx, repo, baseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("get %s: %w", baseBranch, err)
+	}
+	message, err := svc.getCommitMessage(ctx, repo, head)
+	if err != nil {
+		return nil, fmt.Errorf("get head commit: %w", err)
+	}
+	if !isReleaseCommitMessage(message, tag) {
+		return nil, nil
+	}
+
+	rel, err := findExistingRelease(ctx, svc, repo, tag, head)
+	if err != nil {
+		if errors.Is(err, errTagConflict) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rel, nil
 }
@@ -0,0 +1,213 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_findExistingRelease(t *testing.T) {
+	t.Parallel()
+
+	existing := &github.RepositoryRelease{
+		TagName: github.String("v1.0.1"),
+		HTMLURL: github.String("https://github.com/test-owner/test-repo/releases/tag/v1.0.1"),
+	}
+	notFound := &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	tagRef := func(typ, sha string) *github.Reference {
+		return &github.Reference{Object: &github.GitObject{Type: github.String(typ), SHA: github.String(sha)}}
+	}
+	headRef := &github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("head-sha")}}
+
+	tests := []struct {
+		name     string
+		target   string
+		injector func(*MockgitHubRepositoriesClient, *MockgitHubGitClient)
+		want     *github.RepositoryRelease
+		wantErr  error
+	}{
+		{
+			name:   "tag not found",
+			target: "",
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(nil, notFound, errors.New("not found"))
+			},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name:   "release exists on the head of base branch",
+			target: "",
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(tagRef("commit", "head-sha"), nil, nil)
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).Return(headRef, nil, nil)
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(existing, nil, nil)
+			},
+			want:    existing,
+			wantErr: nil,
+		},
+		{
+			name:   "annotated tag on the target",
+			target: "commit-sha",
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(tagRef("tag", "tag-sha"), nil, nil)
+				gitCli.EXPECT().GetTag(gomock.Any(), "test-owner", "test-repo", "tag-sha").
+					Return(&github.Tag{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("commit-sha")}}, nil, nil)
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(existing, nil, nil)
+			},
+			want:    existing,
+			wantErr: nil,
+		},
+		{
+			name:   "tag exists without release",
+			target: "commit-sha",
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(tagRef("commit", "commit-sha"), nil, nil)
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(nil, notFound, errors.New("not found"))
+			},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name:   "tag points to another commit",
+			target: "",
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(tagRef("commit", "old-sha"), nil, nil)
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).Return(headRef, nil, nil)
+			},
+			want:    nil,
+			wantErr: errTagConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			gitCli := NewMockgitHubGitClient(ctrl)
+			tt.injector(repoCli, gitCli)
+
+			svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, git: gitCli})
+			got, err := findExistingRelease(context.Background(), svc, "test-repo", "v1.0.1", tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("findExistingRelease() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("findExistingRelease() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_release_retryAfterLostResponse(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repoCli := NewMockgitHubRepositoriesClient(ctrl)
+	gitCli := NewMockgitHubGitClient(ctrl)
+
+	// v1.0.1 was created by the previous run whose response was lost, so it is already the latest release
+	created := &github.RepositoryRelease{
+		TagName:     github.String("v1.0.1"),
+		HTMLURL:     github.String("https://github.com/test-owner/test-repo/releases/tag/v1.0.1"),
+		PublishedAt: &github.Timestamp{},
+	}
+	repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").Return(created, nil, nil)
+	gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").
+		Return(&github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("head-sha")}}, nil, nil)
+	gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).
+		Return(&github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("head-sha")}}, nil, nil)
+	gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "head-sha").
+		Return(&github.Commit{Message: github.String("Release v1.0.1")}, nil, nil)
+	repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(created, nil, nil)
+
+	svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, git: gitCli})
+	got, err := release(context.Background(), &Config{}, svc, ioutil.Discard, nil, "test-repo", "patch", ReleaseOptions{})
+	if err != nil {
+		t.Fatalf("release() error = %v", err)
+	}
+	if !cmp.Equal(got, created) {
+		t.Errorf("release() diff=%s", cmp.Diff(got, created))
+	}
+}
+
+func Test_findRetriedRelease(t *testing.T) {
+	t.Parallel()
+
+	rel := &github.RepositoryRelease{TagName: github.String("v1.0.1")}
+	tests := []struct {
+		name    string
+		message string
+		tagSHA  string
+		want    *github.RepositoryRelease
+	}{
+		{
+			name:    "head is the release commit",
+			message: "Release v1.0.1",
+			tagSHA:  "head-sha",
+			want:    rel,
+		},
+		{
+			name:    "head is the squashed release pull request",
+			message: "Release v1.0.1 (#12)\n\nbody",
+			tagSHA:  "head-sha",
+			want:    rel,
+		},
+		{
+			name:    "head is not made by mikku",
+			message: "Fix typo (#13)",
+			want:    nil,
+		},
+		{
+			name:    "head is the release commit of another tag",
+			message: "Release v1.0.10",
+			want:    nil,
+		},
+		{
+			name:    "tag points to another commit",
+			message: "Release v1.0.1",
+			tagSHA:  "old-sha",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			gitCli := NewMockgitHubGitClient(ctrl)
+
+			gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "heads/"+baseBranch).
+				Return(&github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String("head-sha")}}, nil, nil)
+			gitCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "head-sha").
+				Return(&github.Commit{Message: github.String(tt.message)}, nil, nil)
+			if tt.tagSHA != "" {
+				gitCli.EXPECT().GetRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").
+					Return(&github.Reference{Object: &github.GitObject{Type: github.String("commit"), SHA: github.String(tt.tagSHA)}}, nil, nil)
+			}
+			if tt.want != nil {
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(rel, nil, nil)
+			}
+
+			svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, git: gitCli})
+			got, err := findRetriedRelease(context.Background(), svc, "test-repo", "v1.0.1")
+			if err != nil {
+				t.Fatalf("findRetriedRelease() error = %v", err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("findRetriedRelease() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
@@ -6,35 +6,36 @@ package mikku
 
 import (
 	context "context"
+	reflect "reflect"
+
 	gomock "github.com/golang/mock/gomock"
 	github "github.com/google/go-github/v32/github"
-	reflect "reflect"
 )
 
-// MockgitHubRepositoriesClient is a mock of gitHubRepositoriesClient interface
+// MockgitHubRepositoriesClient is a mock of gitHubRepositoriesClient interface.
 type MockgitHubRepositoriesClient struct {
 	ctrl     *gomock.Controller
 	recorder *MockgitHubRepositoriesClientMockRecorder
 }
 
-// MockgitHubRepositoriesClientMockRecorder is the mock recorder for MockgitHubRepositoriesClient
+// MockgitHubRepositoriesClientMockRecorder is the mock recorder for MockgitHubRepositoriesClient.
 type MockgitHubRepositoriesClientMockRecorder struct {
 	mock *MockgitHubRepositoriesClient
 }
 
-// NewMockgitHubRepositoriesClient creates a new mock instance
+// NewMockgitHubRepositoriesClient creates a new mock instance.
 func NewMockgitHubRepositoriesClient(ctrl *gomock.Controller) *MockgitHubRepositoriesClient {
 	mock := &MockgitHubRepositoriesClient{ctrl: ctrl}
 	mock.recorder = &MockgitHubRepositoriesClientMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockgitHubRepositoriesClient) EXPECT() *MockgitHubRepositoriesClientMockRecorder {
 	return m.recorder
 }
 
-// CreateRelease mocks base method
+// CreateRelease mocks base method.
 func (m *MockgitHubRepositoriesClient) CreateRelease(ctx context.Context, owner, repo string, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateRelease", ctx, owner, repo, release)
@@ -44,13 +45,93 @@ func (m *MockgitHubRepositoriesClient) CreateRelease(ctx context.Context, owner,
 	return ret0, ret1, ret2
 }
 
-// CreateRelease indicates an expected call of CreateRelease
+// CreateRelease indicates an expected call of CreateRelease.
 func (mr *MockgitHubRepositoriesClientMockRecorder) CreateRelease(ctx, owner, repo, release interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRelease", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).CreateRelease), ctx, owner, repo, release)
 }
 
-// GetLatestRelease mocks base method
+// DeleteRelease mocks base method.
+func (m *MockgitHubRepositoriesClient) DeleteRelease(ctx context.Context, owner, repo string, id int64) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelease", ctx, owner, repo, id)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRelease indicates an expected call of DeleteRelease.
+func (mr *MockgitHubRepositoriesClientMockRecorder) DeleteRelease(ctx, owner, repo, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelease", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).DeleteRelease), ctx, owner, repo, id)
+}
+
+// EditRelease mocks base method.
+func (m *MockgitHubRepositoriesClient) EditRelease(ctx context.Context, owner, repo string, id int64, release *github.RepositoryRelease) (*github.RepositoryRelease, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditRelease", ctx, owner, repo, id, release)
+	ret0, _ := ret[0].(*github.RepositoryRelease)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EditRelease indicates an expected call of EditRelease.
+func (mr *MockgitHubRepositoriesClientMockRecorder) EditRelease(ctx, owner, repo, id, release interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditRelease", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).EditRelease), ctx, owner, repo, id, release)
+}
+
+// GetBranch mocks base method.
+func (m *MockgitHubRepositoriesClient) GetBranch(ctx context.Context, owner, repo, branch string) (*github.Branch, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranch", ctx, owner, repo, branch)
+	ret0, _ := ret[0].(*github.Branch)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetBranch indicates an expected call of GetBranch.
+func (mr *MockgitHubRepositoriesClientMockRecorder) GetBranch(ctx, owner, repo, branch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranch", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).GetBranch), ctx, owner, repo, branch)
+}
+
+// GetCommit mocks base method.
+func (m *MockgitHubRepositoriesClient) GetCommit(ctx context.Context, owner, repo, sha string) (*github.RepositoryCommit, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommit", ctx, owner, repo, sha)
+	ret0, _ := ret[0].(*github.RepositoryCommit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCommit indicates an expected call of GetCommit.
+func (mr *MockgitHubRepositoriesClientMockRecorder) GetCommit(ctx, owner, repo, sha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommit", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).GetCommit), ctx, owner, repo, sha)
+}
+
+// GetContents mocks base method.
+func (m *MockgitHubRepositoriesClient) GetContents(ctx context.Context, owner, repo, path string, opt *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContents", ctx, owner, repo, path, opt)
+	ret0, _ := ret[0].(*github.RepositoryContent)
+	ret1, _ := ret[1].([]*github.RepositoryContent)
+	ret2, _ := ret[2].(*github.Response)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// GetContents indicates an expected call of GetContents.
+func (mr *MockgitHubRepositoriesClientMockRecorder) GetContents(ctx, owner, repo, path, opt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContents", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).GetContents), ctx, owner, repo, path, opt)
+}
+
+// GetLatestRelease mocks base method.
 func (m *MockgitHubRepositoriesClient) GetLatestRelease(ctx context.Context, owner, repo string) (*github.RepositoryRelease, *github.Response, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetLatestRelease", ctx, owner, repo)
@@ -60,36 +141,84 @@ func (m *MockgitHubRepositoriesClient) GetLatestRelease(ctx context.Context, own
 	return ret0, ret1, ret2
 }
 
-// GetLatestRelease indicates an expected call of GetLatestRelease
+// GetLatestRelease indicates an expected call of GetLatestRelease.
 func (mr *MockgitHubRepositoriesClientMockRecorder) GetLatestRelease(ctx, owner, repo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestRelease", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).GetLatestRelease), ctx, owner, repo)
 }
 
-// MockgitHubPullRequestsClient is a mock of gitHubPullRequestsClient interface
+// GetReleaseByTag mocks base method.
+func (m *MockgitHubRepositoriesClient) GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*github.RepositoryRelease, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReleaseByTag", ctx, owner, repo, tag)
+	ret0, _ := ret[0].(*github.RepositoryRelease)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReleaseByTag indicates an expected call of GetReleaseByTag.
+func (mr *MockgitHubRepositoriesClientMockRecorder) GetReleaseByTag(ctx, owner, repo, tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReleaseByTag", reflect.TypeOf((*MockgitHubRepositoriesClient)(nil).GetReleaseByTag), ctx, owner, repo, tag)
+}
+
+// MockgitHubPullRequestsClient is a mock of gitHubPullRequestsClient interface.
 type MockgitHubPullRequestsClient struct {
 	ctrl     *gomock.Controller
 	recorder *MockgitHubPullRequestsClientMockRecorder
 }
 
-// MockgitHubPullRequestsClientMockRecorder is the mock recorder for MockgitHubPullRequestsClient
+// MockgitHubPullRequestsClientMockRecorder is the mock recorder for MockgitHubPullRequestsClient.
 type MockgitHubPullRequestsClientMockRecorder struct {
 	mock *MockgitHubPullRequestsClient
 }
 
-// NewMockgitHubPullRequestsClient creates a new mock instance
+// NewMockgitHubPullRequestsClient creates a new mock instance.
 func NewMockgitHubPullRequestsClient(ctrl *gomock.Controller) *MockgitHubPullRequestsClient {
 	mock := &MockgitHubPullRequestsClient{ctrl: ctrl}
 	mock.recorder = &MockgitHubPullRequestsClientMockRecorder{mock}
 	return mock
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
+// EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockgitHubPullRequestsClient) EXPECT() *MockgitHubPullRequestsClientMockRecorder {
 	return m.recorder
 }
 
-// List mocks base method
+// Create mocks base method.
+func (m *MockgitHubPullRequestsClient) Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, owner, repo, pull)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockgitHubPullRequestsClientMockRecorder) Create(ctx, owner, repo, pull interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockgitHubPullRequestsClient)(nil).Create), ctx, owner, repo, pull)
+}
+
+// Edit mocks base method.
+func (m *MockgitHubPullRequestsClient) Edit(ctx context.Context, owner, repo string, number int, pull *github.PullRequest) (*github.PullRequest, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Edit", ctx, owner, repo, number, pull)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Edit indicates an expected call of Edit.
+func (mr *MockgitHubPullRequestsClientMockRecorder) Edit(ctx, owner, repo, number, pull interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockgitHubPullRequestsClient)(nil).Edit), ctx, owner, repo, number, pull)
+}
+
+// List mocks base method.
 func (m *MockgitHubPullRequestsClient) List(ctx context.Context, owner, repo string, opt *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "List", ctx, owner, repo, opt)
@@ -99,24 +228,335 @@ func (m *MockgitHubPullRequestsClient) List(ctx context.Context, owner, repo str
 	return ret0, ret1, ret2
 }
 
-// List indicates an expected call of List
+// List indicates an expected call of List.
 func (mr *MockgitHubPullRequestsClientMockRecorder) List(ctx, owner, repo, opt interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockgitHubPullRequestsClient)(nil).List), ctx, owner, repo, opt)
 }
 
-// Create mocks base method
-func (m *MockgitHubPullRequestsClient) Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+// RequestReviewers mocks base method.
+func (m *MockgitHubPullRequestsClient) RequestReviewers(ctx context.Context, owner, repo string, number int, reviewers github.ReviewersRequest) (*github.PullRequest, *github.Response, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, owner, repo, pull)
+	ret := m.ctrl.Call(m, "RequestReviewers", ctx, owner, repo, number, reviewers)
 	ret0, _ := ret[0].(*github.PullRequest)
 	ret1, _ := ret[1].(*github.Response)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
-// Create indicates an expected call of Create
-func (mr *MockgitHubPullRequestsClientMockRecorder) Create(ctx, owner, repo, pull interface{}) *gomock.Call {
+// RequestReviewers indicates an expected call of RequestReviewers.
+func (mr *MockgitHubPullRequestsClientMockRecorder) RequestReviewers(ctx, owner, repo, number, reviewers interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockgitHubPullRequestsClient)(nil).Create), ctx, owner, repo, pull)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestReviewers", reflect.TypeOf((*MockgitHubPullRequestsClient)(nil).RequestReviewers), ctx, owner, repo, number, reviewers)
+}
+
+// MockgitHubIssuesClient is a mock of gitHubIssuesClient interface.
+type MockgitHubIssuesClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockgitHubIssuesClientMockRecorder
+}
+
+// MockgitHubIssuesClientMockRecorder is the mock recorder for MockgitHubIssuesClient.
+type MockgitHubIssuesClientMockRecorder struct {
+	mock *MockgitHubIssuesClient
+}
+
+// NewMockgitHubIssuesClient creates a new mock instance.
+func NewMockgitHubIssuesClient(ctrl *gomock.Controller) *MockgitHubIssuesClient {
+	mock := &MockgitHubIssuesClient{ctrl: ctrl}
+	mock.recorder = &MockgitHubIssuesClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockgitHubIssuesClient) EXPECT() *MockgitHubIssuesClientMockRecorder {
+	return m.recorder
+}
+
+// AddAssignees mocks base method.
+func (m *MockgitHubIssuesClient) AddAssignees(ctx context.Context, owner, repo string, number int, assignees []string) (*github.Issue, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAssignees", ctx, owner, repo, number, assignees)
+	ret0, _ := ret[0].(*github.Issue)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddAssignees indicates an expected call of AddAssignees.
+func (mr *MockgitHubIssuesClientMockRecorder) AddAssignees(ctx, owner, repo, number, assignees interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAssignees", reflect.TypeOf((*MockgitHubIssuesClient)(nil).AddAssignees), ctx, owner, repo, number, assignees)
+}
+
+// AddLabelsToIssue mocks base method.
+func (m *MockgitHubIssuesClient) AddLabelsToIssue(ctx context.Context, owner, repo string, number int, labels []string) ([]*github.Label, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLabelsToIssue", ctx, owner, repo, number, labels)
+	ret0, _ := ret[0].([]*github.Label)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddLabelsToIssue indicates an expected call of AddLabelsToIssue.
+func (mr *MockgitHubIssuesClientMockRecorder) AddLabelsToIssue(ctx, owner, repo, number, labels interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLabelsToIssue", reflect.TypeOf((*MockgitHubIssuesClient)(nil).AddLabelsToIssue), ctx, owner, repo, number, labels)
+}
+
+// CreateComment mocks base method.
+func (m *MockgitHubIssuesClient) CreateComment(ctx context.Context, owner, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateComment", ctx, owner, repo, number, comment)
+	ret0, _ := ret[0].(*github.IssueComment)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateComment indicates an expected call of CreateComment.
+func (mr *MockgitHubIssuesClientMockRecorder) CreateComment(ctx, owner, repo, number, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateComment", reflect.TypeOf((*MockgitHubIssuesClient)(nil).CreateComment), ctx, owner, repo, number, comment)
+}
+
+// MockgitHubGitClient is a mock of gitHubGitClient interface.
+type MockgitHubGitClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockgitHubGitClientMockRecorder
+}
+
+// MockgitHubGitClientMockRecorder is the mock recorder for MockgitHubGitClient.
+type MockgitHubGitClientMockRecorder struct {
+	mock *MockgitHubGitClient
+}
+
+// NewMockgitHubGitClient creates a new mock instance.
+func NewMockgitHubGitClient(ctrl *gomock.Controller) *MockgitHubGitClient {
+	mock := &MockgitHubGitClient{ctrl: ctrl}
+	mock.recorder = &MockgitHubGitClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockgitHubGitClient) EXPECT() *MockgitHubGitClientMockRecorder {
+	return m.recorder
+}
+
+// CreateCommit mocks base method.
+func (m *MockgitHubGitClient) CreateCommit(ctx context.Context, owner, repo string, commit *github.Commit) (*github.Commit, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCommit", ctx, owner, repo, commit)
+	ret0, _ := ret[0].(*github.Commit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateCommit indicates an expected call of CreateCommit.
+func (mr *MockgitHubGitClientMockRecorder) CreateCommit(ctx, owner, repo, commit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCommit", reflect.TypeOf((*MockgitHubGitClient)(nil).CreateCommit), ctx, owner, repo, commit)
+}
+
+// CreateRef mocks base method.
+func (m *MockgitHubGitClient) CreateRef(ctx context.Context, owner, repo string, ref *github.Reference) (*github.Reference, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRef", ctx, owner, repo, ref)
+	ret0, _ := ret[0].(*github.Reference)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateRef indicates an expected call of CreateRef.
+func (mr *MockgitHubGitClientMockRecorder) CreateRef(ctx, owner, repo, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRef", reflect.TypeOf((*MockgitHubGitClient)(nil).CreateRef), ctx, owner, repo, ref)
+}
+
+// CreateTree mocks base method.
+func (m *MockgitHubGitClient) CreateTree(ctx context.Context, owner, repo, baseTree string, entries []*github.TreeEntry) (*github.Tree, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTree", ctx, owner, repo, baseTree, entries)
+	ret0, _ := ret[0].(*github.Tree)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTree indicates an expected call of CreateTree.
+func (mr *MockgitHubGitClientMockRecorder) CreateTree(ctx, owner, repo, baseTree, entries interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockgitHubGitClient)(nil).CreateTree), ctx, owner, repo, baseTree, entries)
+}
+
+// DeleteRef mocks base method.
+func (m *MockgitHubGitClient) DeleteRef(ctx context.Context, owner, repo, ref string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRef", ctx, owner, repo, ref)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRef indicates an expected call of DeleteRef.
+func (mr *MockgitHubGitClientMockRecorder) DeleteRef(ctx, owner, repo, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRef", reflect.TypeOf((*MockgitHubGitClient)(nil).DeleteRef), ctx, owner, repo, ref)
+}
+
+// GetCommit mocks base method.
+func (m *MockgitHubGitClient) GetCommit(ctx context.Context, owner, repo, sha string) (*github.Commit, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommit", ctx, owner, repo, sha)
+	ret0, _ := ret[0].(*github.Commit)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetCommit indicates an expected call of GetCommit.
+func (mr *MockgitHubGitClientMockRecorder) GetCommit(ctx, owner, repo, sha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommit", reflect.TypeOf((*MockgitHubGitClient)(nil).GetCommit), ctx, owner, repo, sha)
+}
+
+// GetRef mocks base method.
+func (m *MockgitHubGitClient) GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRef", ctx, owner, repo, ref)
+	ret0, _ := ret[0].(*github.Reference)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRef indicates an expected call of GetRef.
+func (mr *MockgitHubGitClientMockRecorder) GetRef(ctx, owner, repo, ref interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRef", reflect.TypeOf((*MockgitHubGitClient)(nil).GetRef), ctx, owner, repo, ref)
+}
+
+// GetTag mocks base method.
+func (m *MockgitHubGitClient) GetTag(ctx context.Context, owner, repo, sha string) (*github.Tag, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTag", ctx, owner, repo, sha)
+	ret0, _ := ret[0].(*github.Tag)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTag indicates an expected call of GetTag.
+func (mr *MockgitHubGitClientMockRecorder) GetTag(ctx, owner, repo, sha interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTag", reflect.TypeOf((*MockgitHubGitClient)(nil).GetTag), ctx, owner, repo, sha)
+}
+
+// UpdateRef mocks base method.
+func (m *MockgitHubGitClient) UpdateRef(ctx context.Context, owner, repo string, ref *github.Reference, force bool) (*github.Reference, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRef", ctx, owner, repo, ref, force)
+	ret0, _ := ret[0].(*github.Reference)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateRef indicates an expected call of UpdateRef.
+func (mr *MockgitHubGitClientMockRecorder) UpdateRef(ctx, owner, repo, ref, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRef", reflect.TypeOf((*MockgitHubGitClient)(nil).UpdateRef), ctx, owner, repo, ref, force)
+}
+
+// MockgitHubReleaseNotesClient is a mock of gitHubReleaseNotesClient interface.
+type MockgitHubReleaseNotesClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockgitHubReleaseNotesClientMockRecorder
+}
+
+// MockgitHubReleaseNotesClientMockRecorder is the mock recorder for MockgitHubReleaseNotesClient.
+type MockgitHubReleaseNotesClientMockRecorder struct {
+	mock *MockgitHubReleaseNotesClient
+}
+
+// NewMockgitHubReleaseNotesClient creates a new mock instance.
+func NewMockgitHubReleaseNotesClient(ctrl *gomock.Controller) *MockgitHubReleaseNotesClient {
+	mock := &MockgitHubReleaseNotesClient{ctrl: ctrl}
+	mock.recorder = &MockgitHubReleaseNotesClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockgitHubReleaseNotesClient) EXPECT() *MockgitHubReleaseNotesClientMockRecorder {
+	return m.recorder
+}
+
+// GenerateReleaseNotes mocks base method.
+func (m *MockgitHubReleaseNotesClient) GenerateReleaseNotes(ctx context.Context, owner, repo string, opt *generateNotesOptions) (*releaseNotes, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateReleaseNotes", ctx, owner, repo, opt)
+	ret0, _ := ret[0].(*releaseNotes)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GenerateReleaseNotes indicates an expected call of GenerateReleaseNotes.
+func (mr *MockgitHubReleaseNotesClientMockRecorder) GenerateReleaseNotes(ctx, owner, repo, opt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateReleaseNotes", reflect.TypeOf((*MockgitHubReleaseNotesClient)(nil).GenerateReleaseNotes), ctx, owner, repo, opt)
+}
+
+// MockgitHubGraphQLClient is a mock of gitHubGraphQLClient interface.
+type MockgitHubGraphQLClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockgitHubGraphQLClientMockRecorder
+}
+
+// MockgitHubGraphQLClientMockRecorder is the mock recorder for MockgitHubGraphQLClient.
+type MockgitHubGraphQLClientMockRecorder struct {
+	mock *MockgitHubGraphQLClient
+}
+
+// NewMockgitHubGraphQLClient creates a new mock instance.
+func NewMockgitHubGraphQLClient(ctrl *gomock.Controller) *MockgitHubGraphQLClient {
+	mock := &MockgitHubGraphQLClient{ctrl: ctrl}
+	mock.recorder = &MockgitHubGraphQLClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockgitHubGraphQLClient) EXPECT() *MockgitHubGraphQLClientMockRecorder {
+	return m.recorder
+}
+
+// Mutate mocks base method.
+func (m *MockgitHubGraphQLClient) Mutate(ctx context.Context, mutation string, variables map[string]interface{}, result interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mutate", ctx, mutation, variables, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Mutate indicates an expected call of Mutate.
+func (mr *MockgitHubGraphQLClientMockRecorder) Mutate(ctx, mutation, variables, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mutate", reflect.TypeOf((*MockgitHubGraphQLClient)(nil).Mutate), ctx, mutation, variables, result)
+}
+
+// Query mocks base method.
+func (m *MockgitHubGraphQLClient) Query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Query", ctx, query, variables, result)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockgitHubGraphQLClientMockRecorder) Query(ctx, query, variables, result interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockgitHubGraphQLClient)(nil).Query), ctx, query, variables, result)
 }
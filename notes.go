@@ -0,0 +1,244 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	notesFormatMarkdown = "markdown"
+	notesFormatHTML     = "html"
+	notesFormatPlain    = "plain"
+	notesFormatJSON     = "json"
+)
+
+const (
+	htmlNotesTemplate = `<h2>Changelog</h2>
+<ul>{{ range $i, $pr := .PullRequests }}
+<li><a href="{{ $pr.HTMLURL }}">{{ $pr.Title }} (#{{ $pr.Number }})</a> by <a href="{{ $pr.User.HTMLURL }}">@{{ $pr.User.Login }}</a></li>{{ end }}{{ if .DependencyUpdates }}
+<li>Dependency updates ({{ len .DependencyUpdates }})</li>{{ end }}
+</ul>
+{{ if .NewContributors }}<h2>New Contributors</h2>
+<ul>{{ range $i, $c := .NewContributors }}
+<li>@{{ $c.Login }} made their first contribution in <a href="{{ $c.FirstPullRequest.HTMLURL }}">#{{ $c.FirstPullRequest.Number }}</a></li>{{ end }}
+</ul>
+{{ end }}`
+
+	plainNotesTemplate = `Changelog
+{{ range $i, $pr := .PullRequests }}
+- {{ $pr.Title }} (#{{ $pr.Number }}) by {{ $pr.User.Login }}{{ end }}{{ if .DependencyUpdates }}
+- Dependency updates ({{ len .DependencyUpdates }}){{ end }}
+{{ if .NewContributors }}
+New Contributors
+{{ range $i, $c := .NewContributors }}
+- {{ $c.Login }} made their first contribution in #{{ $c.FirstPullRequest.Number }}{{ end }}
+{{ end }}`
+)
+
+var (
+	errInvalidNotesFormat = errors.New("format should be markdown, html, plain or json")
+	errInvalidNotesRange  = errors.New("--to should be after --from")
+)
+
+// ChangelogOptions represents optional settings of `mikku changelog` command
+type ChangelogOptions struct {
+	// From is the tag, branch or commit where the range starts. It is excluded.
+	From string
+	// To is the tag, branch or commit where the range ends. If it is empty, the range ends now.
+	To string
+	// Format is markdown, html, plain or json
+	Format string
+	// Output is the path of the output file. If it is empty, the notes are written to stdout.
+	Output string
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+}
+
+// Changelog is the entry point of `mikku changelog` command
+func Changelog(ctx context.Context, repo string, opts ChangelogOptions) error {
+	switch opts.Format {
+	case notesFormatMarkdown, notesFormatHTML, notesFormatPlain, notesFormatJSON:
+	default:
+		return fmt.Errorf("changelog: %s: %w", opts.Format, errInvalidNotesFormat)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	note, err := collectNotesBetween(ctx, cfg, svc, repo, opts.From, opts.To, time.Now())
+	if err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	to := opts.To
+	if to == "" {
+		to = baseBranch
+	}
+	out, err := renderNotes(note, opts.Format, repo, opts.From, to)
+	if err != nil {
+		return fmt.Errorf("changelog: %w", err)
+	}
+
+	if opts.Output == "" {
+		_, _ = fmt.Fprint(os.Stdout, out)
+		return nil
+	}
+	if err := ioutil.WriteFile(opts.Output, []byte(out), 0644); err != nil {
+		return fmt.Errorf("changelog: write %s: %w", opts.Output, err)
+	}
+	return nil
+}
+
+// collectNotesBetween collects the pull requests merged after from until to
+// If to is empty, the pull requests merged until now are collected.
+func collectNotesBetween(ctx context.Context, cfg *Config, svc *githubClient, repo, from, to string, now time.Time) (*releaseNote, error) {
+	after, err := svc.getCommitDate(ctx, repo, from)
+	if err != nil {
+		return nil, fmt.Errorf("get date of %s: %w", from, err)
+	}
+
+	until := now
+	if to != "" {
+		until, err = svc.getCommitDate(ctx, repo, to)
+		if err != nil {
+			return nil, fmt.Errorf("get date of %s: %w", to, err)
+		}
+	}
+	if !until.After(after) {
+		return nil, errInvalidNotesRange
+	}
+
+	merged, err := svc.getMergedPRsAfter(ctx, repo, after)
+	if err != nil {
+		return nil, fmt.Errorf("get pull requests: %w", err)
+	}
+	prs := make([]*github.PullRequest, 0, len(merged))
+	for _, pr := range merged {
+		if !pr.GetMergedAt().After(until) {
+			prs = append(prs, pr)
+		}
+	}
+
+	prs, dependencyUpdates := filterPRs(prs, cfg.repository(repo).Changelog)
+
+	contributors, err := collectContributors(ctx, svc, repo, prs, after, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect contributors: %w", err)
+	}
+
+	return &releaseNote{
+		PullRequests:      prs,
+		DependencyUpdates: dependencyUpdates,
+		Contributors:      contributors,
+	}, nil
+}
+
+// renderNotes renders the notes in the format
+// Markdown is rendered with the same template as the release body.
+func renderNotes(note *releaseNote, format, repo, from, to string) (string, error) {
+	switch format {
+	case notesFormatMarkdown:
+		return generateReleaseBody(note)
+	case notesFormatPlain:
+		tmpl, err := template.New("plain").Parse(plainNotesTemplate)
+		if err != nil {
+			return "", fmt.Errorf("template parse error: %w", err)
+		}
+		buff := bytes.NewBuffer([]byte{})
+		if err := tmpl.Execute(buff, note); err != nil {
+			return "", fmt.Errorf("template execute error: %w", err)
+		}
+		return buff.String(), nil
+	case notesFormatHTML:
+		// html/template escapes titles written by contributors
+		tmpl, err := htmltemplate.New("html").Parse(htmlNotesTemplate)
+		if err != nil {
+			return "", fmt.Errorf("template parse error: %w", err)
+		}
+		buff := bytes.NewBuffer([]byte{})
+		if err := tmpl.Execute(buff, note); err != nil {
+			return "", fmt.Errorf("template execute error: %w", err)
+		}
+		return buff.String(), nil
+	case notesFormatJSON:
+		buff := bytes.NewBuffer([]byte{})
+		enc := json.NewEncoder(buff)
+		enc.SetIndent("", "  ")
+		// Titles are not embedded in HTML, so keep them readable
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(newNotesJSON(note, repo, from, to)); err != nil {
+			return "", fmt.Errorf("encode notes: %w", err)
+		}
+		return buff.String(), nil
+	default:
+		return "", fmt.Errorf("%s: %w", format, errInvalidNotesFormat)
+	}
+}
+
+// notesJSON represents the notes rendered in JSON
+type notesJSON struct {
+	Repository        string              `json:"repository"`
+	From              string              `json:"from"`
+	To                string              `json:"to"`
+	PullRequests      []*notesPullRequest `json:"pullRequests"`
+	DependencyUpdates []*notesPullRequest `json:"dependencyUpdates"`
+	NewContributors   []string            `json:"newContributors"`
+}
+
+type notesPullRequest struct {
+	Number   int        `json:"number"`
+	Title    string     `json:"title"`
+	URL      string     `json:"url"`
+	Author   string     `json:"author"`
+	MergedAt *time.Time `json:"mergedAt,omitempty"`
+	Labels   []string   `json:"labels"`
+}
+
+func newNotesJSON(note *releaseNote, repo, from, to string) *notesJSON {
+	convert := func(prs []*github.PullRequest) []*notesPullRequest {
+		converted := make([]*notesPullRequest, 0, len(prs))
+		for _, pr := range prs {
+			labels := make([]string, 0, len(pr.Labels))
+			for _, l := range pr.Labels {
+				labels = append(labels, l.GetName())
+			}
+			converted = append(converted, &notesPullRequest{
+				Number:   pr.GetNumber(),
+				Title:    pr.GetTitle(),
+				URL:      pr.GetHTMLURL(),
+				Author:   pr.GetUser().GetLogin(),
+				MergedAt: pr.MergedAt,
+				Labels:   labels,
+			})
+		}
+		return converted
+	}
+
+	newContributors := make([]string, 0)
+	for _, c := range note.NewContributors() {
+		newContributors = append(newContributors, c.Login)
+	}
+
+	return &notesJSON{
+		Repository:        repo,
+		From:              from,
+		To:                to,
+		PullRequests:      convert(note.PullRequests),
+		DependencyUpdates: convert(note.DependencyUpdates),
+		NewContributors:   newContributors,
+	}
+}
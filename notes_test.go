@@ -0,0 +1,195 @@
+package mikku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_collectNotesBetween(t *testing.T) {
+	t.Parallel()
+
+	// The pull requests are returned by GraphQL API. The author is a pointer to an anonymous struct, so they are decoded from JSON.
+	searched := &mergedPRsResult{}
+	if err := json.Unmarshal([]byte(`{"search":{"nodes":[
+		{"number":3,"title":"Add status command","mergedAt":"2019-01-06T00:00:00Z","updatedAt":"2019-01-06T00:00:00Z","author":{"login":"test-owner"}},
+		{"number":2,"title":"Add changelog command","mergedAt":"2019-01-03T00:00:00Z","updatedAt":"2019-01-03T00:00:00Z","author":{"login":"test-owner"}}
+	]}}`), searched); err != nil {
+		t.Fatal(err)
+	}
+	inRange := searched.Search.Nodes[1].toPullRequest()
+
+	commitAt := func(date time.Time) *github.RepositoryCommit {
+		return &github.RepositoryCommit{Commit: &github.Commit{Committer: &github.CommitAuthor{Date: &date}}}
+	}
+
+	tests := []struct {
+		name     string
+		to       string
+		injector func(*MockgitHubRepositoriesClient, *MockgitHubPullRequestsClient, *MockgitHubGraphQLClient)
+		want     *releaseNote
+		wantErr  error
+	}{
+		{
+			name: "pull requests between tags",
+			to:   "v1.1.0",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, graphqlCli *MockgitHubGraphQLClient) {
+				repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "v1.0.0").
+					Return(commitAt(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), nil, nil)
+				repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "v1.1.0").
+					Return(commitAt(time.Date(2019, 1, 5, 0, 0, 0, 0, time.UTC)), nil, nil)
+				graphqlCli.EXPECT().Query(gomock.Any(), mergedPRsQuery, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+						*result.(*mergedPRsResult) = *searched
+						return nil
+					})
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return(nil, &github.Response{}, nil)
+				graphqlCli.EXPECT().Query(gomock.Any(), contributionsQuery(1), map[string]interface{}{
+					"q0": "repo:test-owner/test-repo is:pr is:merged author:test-owner merged:<2019-01-01T00:00:00Z",
+				}, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ map[string]interface{}, result interface{}) error {
+					return json.Unmarshal([]byte(`{"s0":{"issueCount":1}}`), result)
+				})
+			},
+			want: &releaseNote{
+				PullRequests: []*github.PullRequest{inRange},
+				Contributors: []*contributor{{Login: "test-owner", FirstTime: false, FirstPullRequest: inRange}},
+			},
+			wantErr: nil,
+		},
+		{
+			name: "from not found",
+			to:   "",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, graphqlCli *MockgitHubGraphQLClient) {
+				repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "v1.0.0").
+					Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found"))
+			},
+			want:    nil,
+			wantErr: errRefNotFound,
+		},
+		{
+			name: "to is before from",
+			to:   "v0.9.0",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient, graphqlCli *MockgitHubGraphQLClient) {
+				repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "v1.0.0").
+					Return(commitAt(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), nil, nil)
+				repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "test-repo", "v0.9.0").
+					Return(commitAt(time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)), nil, nil)
+			},
+			want:    nil,
+			wantErr: errInvalidNotesRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			prCli := NewMockgitHubPullRequestsClient(ctrl)
+			graphqlCli := NewMockgitHubGraphQLClient(ctrl)
+			tt.injector(repoCli, prCli, graphqlCli)
+
+			svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, pullRequests: prCli, graphql: graphqlCli})
+			got, err := collectNotesBetween(context.Background(), &Config{}, svc, "test-repo", "v1.0.0", tt.to, time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("collectNotesBetween() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("collectNotesBetween() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_renderNotes(t *testing.T) {
+	t.Parallel()
+
+	pr := &github.PullRequest{
+		Number:   github.Int(1),
+		Title:    github.String("Support <html> output"),
+		HTMLURL:  github.String("https://github.com/test-owner/test-repo/pull/1"),
+		User:     &github.User{Login: github.String("Bob"), HTMLURL: github.String("https://github.com/Bob")},
+		MergedAt: timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+		Labels:   []*github.Label{{Name: github.String("enhancement")}},
+	}
+	note := &releaseNote{
+		PullRequests: []*github.PullRequest{pr},
+		Contributors: []*contributor{{Login: "Bob", FirstTime: true, FirstPullRequest: pr}},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{
+			name:   "plain",
+			format: notesFormatPlain,
+			want: `Changelog
+
+- Support <html> output (#1) by Bob
+
+New Contributors
+
+- Bob made their first contribution in #1
+`,
+		},
+		{
+			name:   "html",
+			format: notesFormatHTML,
+			want: `<h2>Changelog</h2>
+<ul>
+<li><a href="https://github.com/test-owner/test-repo/pull/1">Support &lt;html&gt; output (#1)</a> by <a href="https://github.com/Bob">@Bob</a></li>
+</ul>
+<h2>New Contributors</h2>
+<ul>
+<li>@Bob made their first contribution in <a href="https://github.com/test-owner/test-repo/pull/1">#1</a></li>
+</ul>
+`,
+		},
+		{
+			name:   "json",
+			format: notesFormatJSON,
+			want: `{
+  "repository": "test-repo",
+  "from": "v1.0.0",
+  "to": "v1.1.0",
+  "pullRequests": [
+    {
+      "number": 1,
+      "title": "Support <html> output",
+      "url": "https://github.com/test-owner/test-repo/pull/1",
+      "author": "Bob",
+      "mergedAt": "2019-01-02T00:00:00Z",
+      "labels": [
+        "enhancement"
+      ]
+    }
+  ],
+  "dependencyUpdates": [],
+  "newContributors": [
+    "Bob"
+  ]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderNotes(note, tt.format, "test-repo", "v1.0.0", "v1.1.0")
+			if err != nil {
+				t.Fatalf("renderNotes() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderNotes() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	errEnvironmentNotFound = errors.New("environment is not configured")
+	errSameEnvironment     = errors.New("--from and --to should be different environments")
+	errDeployedTagNotFound = errors.New("deployed tag not found")
+	errInconsistentTags    = errors.New("manifests of the environment use different tags")
+)
+
+// PromoteOptions represents optional settings of `mikku promote` command
+type PromoteOptions struct {
+	// From is the environment whose deployed tag is promoted
+	From string
+	// To is the environment the tag is written to
+	To string
+	// DryRun prints the diff of the manifests and the skipped files instead of opening the pull request
+	DryRun bool
+	// Settings is merged into the pull request settings of the target environment in the config file
+	Settings *PullRequestConfig
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+}
+
+// promotion represents the tag promoted from an environment to another one
+type promotion struct {
+	repo string
+	from string
+	to   string
+	tag  string
+	// digest is the digest the source environment pins the image by. It is empty if it is not pinned.
+	digest string
+	// currentTag is the tag deployed to the target environment. It is empty if it is unknown.
+	currentTag string
+	// changelog is the release notes between currentTag and tag. It is empty if they can't be collected.
+	changelog string
+}
+
+// Promote is the entry point of `mikku promote` command
+// It opens a pull request writing the tag deployed to the source environment into the target environment.
+func Promote(ctx context.Context, repo string, opts PromoteOptions) error {
+	if opts.From == opts.To {
+		return fmt.Errorf("promote: %w", errSameEnvironment)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	mc := cfg.repository(repo).Manifest
+	if mc == nil {
+		return fmt.Errorf("promote: %s: %w", repo, errManifestNotConfigured)
+	}
+	from, err := mc.environment(opts.From)
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+	to, err := mc.environment(opts.To)
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+	settings := to.PullRequest.merge(opts.Settings)
+	if err := settings.validate(); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	manifests, err := openManifestRepository(ctx, svc, mc)
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+	defer closeManifestRepository(os.Stderr, manifests)
+	read := manifests.read
+
+	tag, digest, err := readDeployedTag(ctx, read, from)
+	if err != nil {
+		return fmt.Errorf("promote: read tag of %s: %w", opts.From, err)
+	}
+
+	plan, err := planManifestChanges(ctx, read, to, &manifestUpdate{repo: repo, image: mc.Image, tag: tag, digest: digest})
+	if err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+	if opts.DryRun {
+		if err := printManifestPlan(os.Stdout, plan); err != nil {
+			return fmt.Errorf("promote: %w", err)
+		}
+	}
+	changes := plan.changes
+	if len(changes) == 0 {
+		if !opts.DryRun {
+			stale := &manifestPullRequest{app: repo, env: opts.To}
+			if err := closeStaleManifestPullRequests(ctx, svc, mc.Repository, stale, tag); err != nil {
+				return fmt.Errorf("promote: %w", err)
+			}
+		}
+		return fmt.Errorf("promote: %s: %w", tag, errNoManifestChanges)
+	}
+	if err := validateManifestChanges(ctx, read, to, changes); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	p := &promotion{repo: repo, from: opts.From, to: opts.To, tag: tag, digest: digest}
+	if err := p.collectChangelog(ctx, cfg, svc, read, to); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+
+	mpr := newPromotionPullRequest(p, changes)
+	mpr.settings = settings
+	if opts.DryRun {
+		_, _ = fmt.Fprintf(os.Stdout, "\nDry run: %q would be committed to %s.\n", mpr.title, mpr.branch())
+		return nil
+	}
+	if err := publishManifestChanges(ctx, svc, mc.Repository, manifests, mpr, changes); err != nil {
+		return fmt.Errorf("promote: %w", err)
+	}
+	return nil
+}
+
+// readDeployedTag returns the image tag and the digest the manifests of the environment use
+// All kustomize overlays and Helm values of the environment should use the same tag.
+func readDeployedTag(ctx context.Context, read readFileFunc, env *ManifestConfig) (string, string, error) {
+	// tags are formatted as `tag` or `tag@digest`
+	var tags []string
+	for _, overlay := range env.Kustomize {
+		path, content, err := findKustomization(ctx, read, overlay)
+		if err != nil {
+			return "", "", fmt.Errorf("get kustomization: %w", err)
+		}
+		tag, digest, err := kustomizeImageTag(content, env.Image)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", path, err)
+		}
+		if digest != "" {
+			tag += "@" + digest
+		}
+		tags = append(tags, tag)
+	}
+
+	if env.Helm != nil {
+		for _, target := range env.Helm.Values {
+			path, key, err := parseHelmValuesTarget(target)
+			if err != nil {
+				return "", "", err
+			}
+			content, err := read(ctx, path)
+			if err != nil {
+				return "", "", fmt.Errorf("get %s: %w", path, err)
+			}
+			node, err := findYAMLScalar([]byte(content), key)
+			if err != nil {
+				if errors.Is(err, errYAMLPathNotFound) {
+					return "", "", fmt.Errorf("%s: %s: %w", path, key, errDeployedTagNotFound)
+				}
+				return "", "", fmt.Errorf("%s: %w", path, err)
+			}
+			tags = append(tags, node.Value)
+		}
+	}
+
+	if len(tags) == 0 {
+		return "", "", errDeployedTagNotFound
+	}
+	for _, tag := range tags[1:] {
+		if tag != tags[0] {
+			return "", "", fmt.Errorf("%s: %w", strings.Join(tags, ", "), errInconsistentTags)
+		}
+	}
+	tag, digest := splitPinnedTag(tags[0])
+	return tag, digest, nil
+}
+
+// splitPinnedTag splits `tag@digest` into the tag and the digest
+func splitPinnedTag(pinned string) (string, string) {
+	if i := strings.Index(pinned, "@"); i >= 0 {
+		return pinned[:i], pinned[i+1:]
+	}
+	return pinned, ""
+}
+
+// collectChangelog collects the release notes between the tag deployed to the target environment and the promoted tag
+// The changelog is left empty if the target environment has no tag, is ahead of the source environment or uses a tag which is not a git ref.
+func (p *promotion) collectChangelog(ctx context.Context, cfg *Config, svc *githubClient, read readFileFunc, to *ManifestConfig) error {
+	current, _, err := readDeployedTag(ctx, read, to)
+	if err != nil {
+		if errors.Is(err, errDeployedTagNotFound) {
+			return nil
+		}
+		return fmt.Errorf("read tag of %s: %w", p.to, err)
+	}
+	p.currentTag = current
+
+	note, err := collectNotesBetween(ctx, cfg, svc, p.repo, current, p.tag, time.Now())
+	if err != nil {
+		// Image tags such as latest or sha-abc123 may not be git refs
+		if errors.Is(err, errInvalidNotesRange) || errors.Is(err, errRefNotFound) {
+			return nil
+		}
+		return fmt.Errorf("collect changelog: %w", err)
+	}
+	p.changelog, err = generateReleaseBody(note)
+	if err != nil {
+		return fmt.Errorf("generate changelog: %w", err)
+	}
+	return nil
+}
+
+func newPromotionPullRequest(p *promotion, changes []*fileChange) *manifestPullRequest {
+	return &manifestPullRequest{
+		app:   p.repo,
+		env:   p.to,
+		title: fmt.Sprintf("Promote %s %s to %s", p.repo, p.tag, p.to),
+		body:  generatePromotionPullRequestBody(p, changes),
+	}
+}
+
+func generatePromotionPullRequestBody(p *promotion, changes []*fileChange) string {
+	body := fmt.Sprintf("Promote `%s` from %s to %s.\n\n", p.tag, p.from, p.to)
+	if p.digest != "" {
+		body += fmt.Sprintf("Digest: `%s`\n\n", p.digest)
+	}
+	if p.currentTag != "" {
+		body += fmt.Sprintf("%s currently uses `%s`.\n\n", p.to, p.currentTag)
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, "- `"+c.path+"`")
+	}
+	body += strings.Join(lines, "\n") + "\n"
+
+	if p.changelog != "" {
+		body += "\n" + p.changelog
+	}
+	return body
+}
@@ -0,0 +1,183 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_readDeployedTag(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"overlays/staging/kustomization.yaml": "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n",
+		"overlays/prod/kustomization.yaml":    "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n",
+		"overlays/empty/kustomization.yaml":   "resources:\n- ../../base\n",
+		"charts/staging/values.yaml":          "image:\n  tag: v1.1.0\n",
+		"charts/prod/values.yaml":             "image:\n  tag: v1.0.0\n",
+		"overlays/pinned/kustomization.yaml":  "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n  digest: sha256:abc\n",
+		"charts/pinned/values.yaml":           "image:\n  tag: v1.1.0@sha256:abc\n",
+	}
+	image := "ghcr.io/p1ass/mikku"
+
+	tests := []struct {
+		name       string
+		env        *ManifestConfig
+		want       string
+		wantDigest string
+		wantErr    error
+	}{
+		{
+			name:    "kustomize",
+			env:     &ManifestConfig{Image: image, Kustomize: []string{"overlays/staging"}},
+			want:    "v1.1.0",
+			wantErr: nil,
+		},
+		{
+			name: "kustomize and helm",
+			env: &ManifestConfig{
+				Image:     image,
+				Kustomize: []string{"overlays/staging"},
+				Helm:      &HelmConfig{Values: []string{"charts/staging/values.yaml:image.tag"}},
+			},
+			want:    "v1.1.0",
+			wantErr: nil,
+		},
+		{
+			name: "pinned by digest",
+			env: &ManifestConfig{
+				Image:     image,
+				Kustomize: []string{"overlays/pinned"},
+				Helm:      &HelmConfig{Values: []string{"charts/pinned/values.yaml:image.tag"}},
+			},
+			want:       "v1.1.0",
+			wantDigest: "sha256:abc",
+			wantErr:    nil,
+		},
+		{
+			name: "different digests",
+			env: &ManifestConfig{
+				Image:     image,
+				Kustomize: []string{"overlays/staging"},
+				Helm:      &HelmConfig{Values: []string{"charts/pinned/values.yaml:image.tag"}},
+			},
+			want:    "",
+			wantErr: errInconsistentTags,
+		},
+		{
+			name: "different tags",
+			env: &ManifestConfig{
+				Image:     image,
+				Kustomize: []string{"overlays/staging"},
+				Helm:      &HelmConfig{Values: []string{"charts/prod/values.yaml:image.tag"}},
+			},
+			want:    "",
+			wantErr: errInconsistentTags,
+		},
+		{
+			name:    "image not found in kustomization",
+			env:     &ManifestConfig{Image: image, Kustomize: []string{"overlays/empty"}},
+			want:    "",
+			wantErr: errDeployedTagNotFound,
+		},
+		{
+			name:    "helm key not found",
+			env:     &ManifestConfig{Image: image, Helm: &HelmConfig{Values: []string{"charts/prod/values.yaml:app.tag"}}},
+			want:    "",
+			wantErr: errDeployedTagNotFound,
+		},
+		{
+			name:    "no targets",
+			env:     &ManifestConfig{Image: image},
+			want:    "",
+			wantErr: errDeployedTagNotFound,
+		},
+		{
+			name:    "overlay not found",
+			env:     &ManifestConfig{Image: image, Kustomize: []string{"overlays/unknown"}},
+			want:    "",
+			wantErr: errFileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, digest, err := readDeployedTag(context.Background(), readFiles(files), tt.env)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("readDeployedTag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want || digest != tt.wantDigest {
+				t.Errorf("readDeployedTag() = (%s, %s), want (%s, %s)", got, digest, tt.want, tt.wantDigest)
+			}
+		})
+	}
+}
+
+func Test_generatePromotionPullRequestBody(t *testing.T) {
+	t.Parallel()
+
+	changes := []*fileChange{{path: "overlays/prod/kustomization.yaml"}}
+
+	tests := []struct {
+		name string
+		p    *promotion
+		want string
+	}{
+		{
+			name: "with changelog",
+			p: &promotion{
+				repo:       "mikku",
+				from:       "staging",
+				to:         "prod",
+				tag:        "v1.1.0",
+				currentTag: "v1.0.0",
+				changelog:  "## Changelog\n- Add feature (#1) by @p1ass\n",
+			},
+			want: "Promote `v1.1.0` from staging to prod.\n\nprod currently uses `v1.0.0`.\n\n- `overlays/prod/kustomization.yaml`\n\n## Changelog\n- Add feature (#1) by @p1ass\n",
+		},
+		{
+			name: "first deployment",
+			p:    &promotion{repo: "mikku", from: "staging", to: "prod", tag: "v1.1.0"},
+			want: "Promote `v1.1.0` from staging to prod.\n\n- `overlays/prod/kustomization.yaml`\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := generatePromotionPullRequestBody(tt.p, changes); got != tt.want {
+				t.Errorf("generatePromotionPullRequestBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_promotion_collectChangelog_tagIsNotRef(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repoCli := NewMockgitHubRepositoriesClient(ctrl)
+	repoCli.EXPECT().GetCommit(gomock.Any(), "test-owner", "mikku", "latest").
+		Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusUnprocessableEntity}}, errors.New("no commit found"))
+
+	files := map[string]string{
+		"overlays/prod/kustomization.yaml": "images:\n- name: ghcr.io/p1ass/mikku\n  newTag: latest\n",
+	}
+	to := &ManifestConfig{Image: "ghcr.io/p1ass/mikku", Kustomize: []string{"overlays/prod"}}
+	p := &promotion{repo: "mikku", from: "staging", to: "prod", tag: "v1.1.0"}
+
+	svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli})
+	if err := p.collectChangelog(context.Background(), &Config{}, svc, readFiles(files), to); err != nil {
+		t.Fatalf("promotion.collectChangelog() error = %v", err)
+	}
+	if p.currentTag != "latest" || p.changelog != "" {
+		t.Errorf("promotion = (%s, %q), want (latest, empty changelog)", p.currentTag, p.changelog)
+	}
+}
@@ -0,0 +1,298 @@
+package mikku
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	dockerHubHost = "docker.io"
+	// dockerHubRegistryHost is the host of Docker Hub which serves the Distribution API
+	dockerHubRegistryHost = "registry-1.docker.io"
+
+	defaultImagePollInterval = 15 * time.Second
+)
+
+// manifestMediaTypes are the manifests accepted by the registry
+// Indexes and manifest lists are preferred so that the digest covers all platforms.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var (
+	errImageNotFound    = errors.New("image not found in the registry")
+	errImageWaitTimeout = errors.New("timed out waiting for the image to be pushed")
+	errInvalidDigest    = errors.New("registry returned invalid digest")
+
+	digestReg        = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	authChallengeReg = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// registryClient calls the OCI Distribution API of container registries
+type registryClient struct {
+	client *http.Client
+	// scheme is https except in tests
+	scheme string
+	// username and password are sent to host or its token realm. The requests to the other registries are anonymous.
+	host     string
+	username string
+	password string
+	// pollInterval is the interval of checking the image while waiting for it
+	pollInterval time.Duration
+}
+
+func newRegistryClient(cfg *Config, requestTimeout time.Duration) *registryClient {
+	return &registryClient{
+		client:       &http.Client{Transport: newRetryTransport(nil, requestTimeout)},
+		scheme:       "https",
+		host:         normalizeRegistryHost(cfg.RegistryHost),
+		username:     cfg.RegistryUsername,
+		password:     cfg.RegistryPassword,
+		pollInterval: defaultImagePollInterval,
+	}
+}
+
+// parseImageReference splits the image into the registry host and the repository
+// Images without a host are on Docker Hub. Ex. nginx → registry-1.docker.io, library/nginx
+func parseImageReference(image string) (string, string) {
+	host, repo := dockerHubRegistryHost, image
+	if i := strings.Index(image, "/"); i > 0 {
+		first := image[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			host, repo = first, image[i+1:]
+		}
+	}
+	host = normalizeRegistryHost(host)
+	if host == dockerHubRegistryHost && !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	return host, repo
+}
+
+// normalizeRegistryHost returns the host serving the Distribution API. Ex. docker.io → registry-1.docker.io
+func normalizeRegistryHost(host string) string {
+	if host == dockerHubHost {
+		return dockerHubRegistryHost
+	}
+	return host
+}
+
+// resolveDigest returns the digest of the manifest of the tag. Ex. sha256:0123...
+func (c *registryClient) resolveDigest(ctx context.Context, image, tag string) (string, error) {
+	u := c.manifestURL(image, tag)
+
+	resp, err := c.do(ctx, http.MethodHead, u)
+	if err != nil {
+		return "", fmt.Errorf("%s:%s: %w", image, tag, err)
+	}
+	_ = resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		if !digestReg.MatchString(digest) {
+			return "", fmt.Errorf("%s: %w", digest, errInvalidDigest)
+		}
+		return digest, nil
+	}
+
+	// The header is optional, so calculate the digest from the manifest
+	resp, err = c.do(ctx, http.MethodGet, u)
+	if err != nil {
+		return "", fmt.Errorf("%s:%s: %w", image, tag, err)
+	}
+	defer resp.Body.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", fmt.Errorf("read manifest: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// imageExists reports whether the manifest of the tag has been pushed
+func (c *registryClient) imageExists(ctx context.Context, image, tag string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.manifestURL(image, tag))
+	if err != nil {
+		if errors.Is(err, errImageNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s:%s: %w", image, tag, err)
+	}
+	_ = resp.Body.Close()
+	return true, nil
+}
+
+// waitForImage checks the image at intervals until it is pushed or the timeout expires
+func (c *registryClient) waitForImage(ctx context.Context, image, tag string, timeout time.Duration) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		exists, err := c.imageExists(waitCtx, image, tag)
+		if err != nil && waitCtx.Err() == nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+		if err := sleepContext(waitCtx, c.pollInterval); err != nil {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%s:%s: %w", image, tag, errImageWaitTimeout)
+}
+
+func (c *registryClient) manifestURL(image, tag string) string {
+	host, repo := parseImageReference(image)
+	return fmt.Sprintf("%s://%s/v2/%s/manifests/%s", c.scheme, host, repo, tag)
+}
+
+// do sends the request to the registry
+// If the registry asks for authentication, the request is sent again with a bearer token or basic auth.
+func (c *registryClient) do(ctx context.Context, method, u string) (*http.Response, error) {
+	resp, err := c.send(ctx, method, u, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		registry, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		authorization, err := c.authorize(ctx, registry, challenge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.send(ctx, method, u, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, errImageNotFound
+	default:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// authorize returns the Authorization header answering the challenge of WWW-Authenticate
+func (c *registryClient) authorize(ctx context.Context, registry *url.URL, challenge string) (string, error) {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch {
+	case scheme == "bearer":
+		token, err := c.fetchToken(ctx, registry, challenge)
+		if err != nil {
+			return "", fmt.Errorf("fetch registry token: %w", err)
+		}
+		return "Bearer " + token, nil
+	case scheme == "basic" && c.sendsCredentials(registry, registry):
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password)), nil
+	default:
+		return "", fmt.Errorf("unsupported challenge: %q", challenge)
+	}
+}
+
+func (c *registryClient) send(ctx context.Context, method, u, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	return resp, nil
+}
+
+// sendsCredentials reports whether the credentials are sent to the destination on behalf of the registry
+// They are sent only for the configured host and never over plain http.
+func (c *registryClient) sendsCredentials(registry, destination *url.URL) bool {
+	return c.username != "" && c.host != "" && registry.Host == c.host && destination.Scheme == "https"
+}
+
+// fetchToken gets a token from the realm of the challenge. Ex. Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:p1ass/mikku:pull"
+// The credentials are sent with basic auth if they are for the registry. Otherwise, an anonymous token is requested.
+func (c *registryClient) fetchToken(ctx context.Context, registry *url.URL, challenge string) (string, error) {
+	params := map[string]string{}
+	for _, m := range authChallengeReg.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("realm not found in challenge: %q", challenge)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", fmt.Errorf("parse realm: %w", err)
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			q.Set(key, params[key])
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	if c.sendsCredentials(registry, u) {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var tr struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", fmt.Errorf("decode token: %w", err)
+	}
+	if tr.Token != "" {
+		return tr.Token, nil
+	}
+	if tr.AccessToken != "" {
+		return tr.AccessToken, nil
+	}
+	return "", errors.New("token is empty")
+}
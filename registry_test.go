@@ -0,0 +1,356 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testDigest = "sha256:0f0bdd2ea6bbf5f0e8dbc3b8e8b1f3c1fbd6e7b8aa0a5a1d6b8e28a3a2a4c0d1"
+
+func Test_parseImageReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image    string
+		wantHost string
+		wantRepo string
+	}{
+		{image: "ghcr.io/p1ass/mikku", wantHost: "ghcr.io", wantRepo: "p1ass/mikku"},
+		{image: "localhost:5000/mikku", wantHost: "localhost:5000", wantRepo: "mikku"},
+		{image: "localhost/mikku", wantHost: "localhost", wantRepo: "mikku"},
+		{image: "p1ass/mikku", wantHost: "registry-1.docker.io", wantRepo: "p1ass/mikku"},
+		{image: "nginx", wantHost: "registry-1.docker.io", wantRepo: "library/nginx"},
+		{image: "docker.io/nginx", wantHost: "registry-1.docker.io", wantRepo: "library/nginx"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.image, func(t *testing.T) {
+			t.Parallel()
+			host, repo := parseImageReference(tt.image)
+			if host != tt.wantHost || repo != tt.wantRepo {
+				t.Errorf("parseImageReference() = (%s, %s), want (%s, %s)", host, repo, tt.wantHost, tt.wantRepo)
+			}
+		})
+	}
+}
+
+// newTestRegistry serves manifests of p1ass/mikku like an OCI registry
+// If token is not empty, the manifests require the bearer token issued by /token.
+func newTestRegistry(t *testing.T, token string, withDigestHeader bool) (*httptest.Server, string) {
+	t.Helper()
+
+	manifest := `{"schemaVersion":2}`
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("scope") != "repository:p1ass/mikku:pull" || r.URL.Query().Get("service") != "test-registry" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"token":%q}`, token)
+	})
+	mux.HandleFunc("/v2/p1ass/mikku/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:p1ass/mikku:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		if strings.TrimPrefix(r.URL.Path, "/v2/p1ass/mikku/manifests/") != "v1.0.0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if withDigestHeader {
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+		if r.Method == http.MethodGet {
+			_, _ = fmt.Fprint(w, manifest)
+		}
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, strings.TrimPrefix(server.URL, "http://") + "/p1ass/mikku"
+}
+
+func Test_registryClient_resolveDigest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		token            string
+		withDigestHeader bool
+		tag              string
+		want             string
+		wantErr          error
+	}{
+		{
+			name:             "digest header",
+			withDigestHeader: true,
+			tag:              "v1.0.0",
+			want:             testDigest,
+		},
+		{
+			name:             "anonymous token",
+			token:            "anonymous",
+			withDigestHeader: true,
+			tag:              "v1.0.0",
+			want:             testDigest,
+		},
+		{
+			name:             "calculate digest from manifest",
+			withDigestHeader: false,
+			tag:              "v1.0.0",
+			// sha256 of {"schemaVersion":2}
+			want: "sha256:bafebd36189ad3688b7b3915ea55d461e0bfcfbdde11e54b0a123999fb6be50f",
+		},
+		{
+			name:             "tag not found",
+			withDigestHeader: true,
+			tag:              "v9.9.9",
+			wantErr:          errImageNotFound,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, image := newTestRegistry(t, tt.token, tt.withDigestHeader)
+			c := &registryClient{client: server.Client(), scheme: "http"}
+
+			got, err := c.resolveDigest(context.Background(), image, tt.tag)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("resolveDigest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("resolveDigest() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_registryClient_imageExists(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		token   string
+		tag     string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "exists",
+			tag:     "v1.0.0",
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "exists with token",
+			token:   "anonymous",
+			tag:     "v1.0.0",
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "not pushed yet",
+			tag:     "v1.1.0",
+			want:    false,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, image := newTestRegistry(t, tt.token, true)
+			c := &registryClient{client: server.Client(), scheme: "http"}
+
+			got, err := c.imageExists(context.Background(), image, tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("imageExists() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("imageExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_registryClient_imageExists_credentials(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		basic    bool
+		password string
+		// otherHost configures the credentials for another registry
+		otherHost bool
+		// insecureRealm serves the token realm over plain http
+		insecureRealm bool
+		want          bool
+		wantErr       bool
+	}{
+		{
+			name:     "token with credentials",
+			basic:    false,
+			password: "secret",
+			want:     true,
+			wantErr:  false,
+		},
+		{
+			name:     "token with wrong credentials",
+			basic:    false,
+			password: "wrong",
+			want:     false,
+			wantErr:  true,
+		},
+		{
+			name:     "basic auth",
+			basic:    true,
+			password: "secret",
+			want:     true,
+			wantErr:  false,
+		},
+		{
+			name:      "credentials of another registry are not sent",
+			basic:     false,
+			password:  "secret",
+			otherHost: true,
+			want:      false,
+			wantErr:   true,
+		},
+		{
+			name:      "basic auth of another registry is not sent",
+			basic:     true,
+			password:  "secret",
+			otherHost: true,
+			want:      false,
+			wantErr:   true,
+		},
+		{
+			name:          "credentials are not sent to plain http realm",
+			basic:         false,
+			password:      "secret",
+			insecureRealm: true,
+			want:          false,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var realm string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+				if user, pass, ok := r.BasicAuth(); !ok || user != "p1ass" || pass != "secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = fmt.Fprint(w, `{"access_token":"private"}`)
+			})
+			mux.HandleFunc("/v2/p1ass/private/manifests/v1.0.0", func(w http.ResponseWriter, r *http.Request) {
+				if tt.basic {
+					if user, pass, ok := r.BasicAuth(); ok && user == "p1ass" && pass == "secret" {
+						return
+					}
+					w.Header().Set("WWW-Authenticate", `Basic realm="test-registry"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer private" {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry"`, realm))
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			})
+			server := httptest.NewTLSServer(mux)
+			defer server.Close()
+			insecure := httptest.NewServer(mux)
+			defer insecure.Close()
+			realm = server.URL
+			if tt.insecureRealm {
+				realm = insecure.URL
+			}
+
+			host := strings.TrimPrefix(server.URL, "https://")
+			c := &registryClient{client: server.Client(), scheme: "https", host: host, username: "p1ass", password: tt.password}
+			if tt.otherHost {
+				c.host = "ghcr.io"
+			}
+
+			got, err := c.imageExists(context.Background(), host+"/p1ass/private", "v1.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("imageExists() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("imageExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_registryClient_waitForImage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// pushedAfter is the number of requests answered with 404 before the image is pushed
+		pushedAfter int32
+		timeout     time.Duration
+		wantErr     error
+	}{
+		{
+			name:        "pushed while waiting",
+			pushedAfter: 2,
+			timeout:     time.Second,
+			wantErr:     nil,
+		},
+		{
+			name:        "timeout",
+			pushedAfter: 1000,
+			timeout:     50 * time.Millisecond,
+			wantErr:     errImageWaitTimeout,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&requests, 1) <= tt.pushedAfter {
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			c := &registryClient{client: server.Client(), scheme: "http", pollInterval: 10 * time.Millisecond}
+			image := strings.TrimPrefix(server.URL, "http://") + "/p1ass/mikku"
+
+			err := c.waitForImage(context.Background(), image, "v1.1.0", tt.timeout)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("waitForImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && atomic.LoadInt32(&requests) != tt.pushedAfter+1 {
+				t.Errorf("waitForImage() sent %d requests, want %d", requests, tt.pushedAfter+1)
+			}
+		})
+	}
+}
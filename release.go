@@ -2,6 +2,8 @@ package mikku
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"text/template"
 
@@ -12,11 +14,51 @@ const (
 	releaseBodyTemplate = `
 ## Changelog
 {{ range $i, $pr := .PullRequests }}
-- {{ $pr.Title }} (#{{ $pr.Number }}) by @{{ $pr.User.Login }}{{ end }}
-`
+- {{ $pr.Title }} (#{{ $pr.Number }}) by @{{ $pr.User.Login }}{{ end }}{{ if .DependencyUpdates }}
+- Dependency updates ({{ len .DependencyUpdates }}){{ end }}
+{{ if .NewContributors }}
+## New Contributors
+{{ range $i, $c := .NewContributors }}
+- @{{ $c.Login }} made their first contribution in #{{ $c.FirstPullRequest.Number }}{{ end }}
+{{ end }}`
 )
 
-func generateReleaseBody(prs []*github.PullRequest) (string, error) {
+const (
+	// notesSourceMikku renders the release body with the mikku template
+	notesSourceMikku = "mikku"
+	// notesSourceGitHub uses the release notes generated by GitHub
+	notesSourceGitHub = "github"
+	// notesSourceBoth appends the release notes generated by GitHub to the mikku template
+	notesSourceBoth = "both"
+)
+
+var (
+	errInvalidNotesSource = errors.New("notes source should be github, mikku or both")
+)
+
+func validateNotesSource(source string) error {
+	switch source {
+	case notesSourceMikku, notesSourceGitHub, notesSourceBoth:
+		return nil
+	default:
+		return fmt.Errorf("%s: %w", source, errInvalidNotesSource)
+	}
+}
+
+// releaseNote represents data rendered in the release body
+type releaseNote struct {
+	PullRequests []*github.PullRequest
+	// DependencyUpdates are folded into a single line
+	DependencyUpdates []*github.PullRequest
+	Contributors      []*contributor
+}
+
+// NewContributors returns first-time contributors in the release
+func (n *releaseNote) NewContributors() []*contributor {
+	return newContributors(n.Contributors)
+}
+
+func generateReleaseBody(note *releaseNote) (string, error) {
 	tmpl, err := template.New("body").Parse(releaseBodyTemplate)
 	if err != nil {
 		return "", fmt.Errorf("template parse error: %w", err)
@@ -24,10 +66,36 @@ func generateReleaseBody(prs []*github.PullRequest) (string, error) {
 
 	buff := bytes.NewBuffer([]byte{})
 
-	body := map[string]interface{}{"PullRequests": prs}
-
-	if err := tmpl.Execute(buff, body); err != nil {
+	if err := tmpl.Execute(buff, note); err != nil {
 		return "", fmt.Errorf("template execute error: %w", err)
 	}
 	return buff.String(), nil
 }
+
+// generateNotes generates the release body from the given source
+// previousTag and target can be empty.
+func generateNotes(ctx context.Context, svc *githubClient, repo, source, newTag, previousTag, target string, note *releaseNote) (string, error) {
+	var body string
+	if source == notesSourceMikku || source == notesSourceBoth {
+		b, err := generateReleaseBody(note)
+		if err != nil {
+			return "", fmt.Errorf("generate release body: %w", err)
+		}
+		body = b
+	}
+
+	if source == notesSourceGitHub || source == notesSourceBoth {
+		if target == "" {
+			target = baseBranch
+		}
+		generated, err := svc.generateReleaseNotes(ctx, repo, newTag, previousTag, target)
+		if err != nil {
+			return "", fmt.Errorf("generate release notes on GitHub: %w", err)
+		}
+		if body != "" {
+			body += "\n"
+		}
+		body += generated
+	}
+	return body, nil
+}
@@ -1,8 +1,10 @@
 package mikku
 
 import (
+	"context"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/google/go-github/v32/github"
 )
 
@@ -10,10 +12,12 @@ func Test_generateReleaseBody(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		prs     []*github.PullRequest
-		want    string
-		wantErr bool
+		name              string
+		prs               []*github.PullRequest
+		dependencyUpdates []*github.PullRequest
+		contributors      []*contributor
+		want              string
+		wantErr           bool
 	}{
 		{
 			name: "No Pull Requests",
@@ -80,13 +84,82 @@ func Test_generateReleaseBody(t *testing.T) {
 
 - Second Pull Request Title (#2) by @test-owner
 - First Pull Request Title (#1) by @test-owner
+`,
+			wantErr: false,
+		},
+		{
+			name: "New Contributors",
+			prs: []*github.PullRequest{
+				{
+					Number: github.Int(2),
+					Title:  github.String("Second Pull Request Title"),
+					User: &github.User{
+						Login: github.String("new-contributor"),
+					},
+				},
+				{
+					Number: github.Int(1),
+					Title:  github.String("First Pull Request Title"),
+					User: &github.User{
+						Login: github.String("test-owner"),
+					},
+				},
+			},
+			contributors: []*contributor{
+				{
+					Login:            "new-contributor",
+					FirstTime:        true,
+					FirstPullRequest: &github.PullRequest{Number: github.Int(2)},
+				},
+				{
+					Login:            "test-owner",
+					FirstTime:        false,
+					FirstPullRequest: &github.PullRequest{Number: github.Int(1)},
+				},
+			},
+			want: `
+## Changelog
+
+- Second Pull Request Title (#2) by @new-contributor
+- First Pull Request Title (#1) by @test-owner
+
+## New Contributors
+
+- @new-contributor made their first contribution in #2
+`,
+			wantErr: false,
+		},
+		{
+			name: "Dependency Updates",
+			prs: []*github.PullRequest{
+				{
+					Number: github.Int(1),
+					Title:  github.String("Pull Request Title"),
+					User: &github.User{
+						Login: github.String("test-owner"),
+					},
+				},
+			},
+			dependencyUpdates: []*github.PullRequest{
+				{Number: github.Int(2)},
+				{Number: github.Int(3)},
+			},
+			want: `
+## Changelog
+
+- Pull Request Title (#1) by @test-owner
+- Dependency updates (2)
 `,
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateReleaseBody(tt.prs)
+			got, err := generateReleaseBody(&releaseNote{
+				PullRequests:      tt.prs,
+				DependencyUpdates: tt.dependencyUpdates,
+				Contributors:      tt.contributors,
+			})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("generateReleaseBody() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -98,3 +171,79 @@ func Test_generateReleaseBody(t *testing.T) {
 		})
 	}
 }
+
+func Test_generateNotes(t *testing.T) {
+	t.Parallel()
+
+	note := &releaseNote{
+		PullRequests: []*github.PullRequest{
+			{
+				Number: github.Int(1),
+				Title:  github.String("Pull Request Title"),
+				User: &github.User{
+					Login: github.String("test-owner"),
+				},
+			},
+		},
+	}
+	mikkuBody := `
+## Changelog
+
+- Pull Request Title (#1) by @test-owner
+`
+	githubBody := "## What's Changed\n* Pull Request Title by @test-owner in #1\n"
+
+	tests := []struct {
+		name     string
+		source   string
+		injector func(cli *MockgitHubReleaseNotesClient)
+		want     string
+	}{
+		{
+			name:     "mikku",
+			source:   notesSourceMikku,
+			injector: func(cli *MockgitHubReleaseNotesClient) {},
+			want:     mikkuBody,
+		},
+		{
+			name:   "github",
+			source: notesSourceGitHub,
+			injector: func(cli *MockgitHubReleaseNotesClient) {
+				cli.EXPECT().GenerateReleaseNotes(gomock.Any(), "test-owner", "test-repo", &generateNotesOptions{
+					TagName:         "v1.1.0",
+					TargetCommitish: baseBranch,
+					PreviousTagName: "v1.0.0",
+				}).Return(&releaseNotes{Name: "v1.1.0", Body: githubBody}, nil, nil)
+			},
+			want: githubBody,
+		},
+		{
+			name:   "both",
+			source: notesSourceBoth,
+			injector: func(cli *MockgitHubReleaseNotesClient) {
+				cli.EXPECT().GenerateReleaseNotes(gomock.Any(), "test-owner", "test-repo", gomock.Any()).
+					Return(&releaseNotes{Name: "v1.1.0", Body: githubBody}, nil, nil)
+			},
+			want: mikkuBody + "\n" + githubBody,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			cli := NewMockgitHubReleaseNotesClient(ctrl)
+			tt.injector(cli)
+
+			s := newGitHubClient("test-owner", gitHubClients{releaseNotes: cli})
+
+			got, err := generateNotes(context.Background(), s, "test-repo", tt.source, "v1.1.0", "v1.0.0", "", note)
+			if err != nil {
+				t.Errorf("generateNotes() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("generateNotes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var errKustomizationCycle = errors.New("kustomizations refer to each other")
+
+// kustomization is the subset of kustomization.yaml which is needed to render the resources
+type kustomization struct {
+	Resources             []string              `yaml:"resources"`
+	Bases                 []string              `yaml:"bases"`
+	Components            []string              `yaml:"components"`
+	PatchesStrategicMerge []string              `yaml:"patchesStrategicMerge"`
+	Patches               []*kustomizationPatch `yaml:"patches"`
+	Images                []*kustomizationImage `yaml:"images"`
+}
+
+type kustomizationPatch struct {
+	Path string `yaml:"path"`
+}
+
+type kustomizationImage struct {
+	Name    string `yaml:"name"`
+	NewName string `yaml:"newName"`
+	NewTag  string `yaml:"newTag"`
+	Digest  string `yaml:"digest"`
+}
+
+// kubernetesResource represents a resource rendered from a kustomization
+type kubernetesResource struct {
+	// source is the file and the index of the document. Ex. base/deployment.yaml#0
+	source string
+	object map[string]interface{}
+}
+
+// renderKustomization renders the resources of the overlay like `kustomize build`
+// The resources, bases and components are resolved recursively and `images:` is applied to the containers.
+// Remote resources are skipped and the other transformers such as patches and generators are not applied,
+// but the files they refer to should exist and be valid YAML.
+func renderKustomization(ctx context.Context, read readFileFunc, dir string) ([]*kubernetesResource, error) {
+	return renderKustomizationDir(ctx, read, path.Clean(dir), map[string]bool{})
+}
+
+func renderKustomizationDir(ctx context.Context, read readFileFunc, dir string, visiting map[string]bool) ([]*kubernetesResource, error) {
+	if visiting[dir] {
+		return nil, fmt.Errorf("%s: %w", dir, errKustomizationCycle)
+	}
+	visiting[dir] = true
+	defer delete(visiting, dir)
+
+	kpath, content, err := findKustomization(ctx, read, dir)
+	if err != nil {
+		return nil, err
+	}
+	var k kustomization
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return nil, fmt.Errorf("%s: parse yaml: %w", kpath, err)
+	}
+
+	var resources []*kubernetesResource
+	for _, entry := range append(append(append([]string{}, k.Resources...), k.Bases...), k.Components...) {
+		if isRemoteKustomizeResource(entry) {
+			continue
+		}
+		p, err := resolveKustomizePath(dir, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kpath, err)
+		}
+
+		if !isYAMLFile(p) {
+			rendered, err := renderKustomizationDir(ctx, read, p, visiting)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, rendered...)
+			continue
+		}
+
+		c, err := read(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: get resource: %w", kpath, err)
+		}
+		objects, err := decodeYAMLDocuments(c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		for i, obj := range objects {
+			resources = append(resources, &kubernetesResource{source: fmt.Sprintf("%s#%d", p, i), object: obj})
+		}
+	}
+
+	patches := append([]string{}, k.PatchesStrategicMerge...)
+	for _, patch := range k.Patches {
+		if patch != nil && patch.Path != "" {
+			patches = append(patches, patch.Path)
+		}
+	}
+	for _, entry := range patches {
+		// Inline patches are written in the kustomization
+		if strings.Contains(entry, "\n") {
+			continue
+		}
+		p, err := resolveKustomizePath(dir, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", kpath, err)
+		}
+		c, err := read(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: get patch: %w", kpath, err)
+		}
+		if _, err := decodeYAMLDocuments(c); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	for _, r := range resources {
+		applyKustomizeImages(r.object, k.Images)
+	}
+	return resources, nil
+}
+
+// decodeYAMLDocuments decodes all documents of the multi-document YAML
+// Empty documents are skipped like kubectl does.
+func decodeYAMLDocuments(content string) ([]map[string]interface{}, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var objects []map[string]interface{}
+	for i := 0; ; i++ {
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("document %d: parse yaml: %w", i, err)
+		}
+		if v == nil {
+			continue
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("document %d: should be a mapping", i)
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// applyKustomizeImages rewrites the images of the containers in the resource as `images:` of the kustomization
+func applyKustomizeImages(v interface{}, images []*kustomizationImage) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if key != "containers" && key != "initContainers" {
+				applyKustomizeImages(child, images)
+				continue
+			}
+			containers, _ := child.([]interface{})
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if image, ok := container["image"].(string); ok {
+					container["image"] = transformKustomizeImage(image, images)
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			applyKustomizeImages(child, images)
+		}
+	}
+}
+
+// transformKustomizeImage returns the image replaced by the first matching entry of `images:`
+func transformKustomizeImage(image string, images []*kustomizationImage) string {
+	name, tag, digest := image, "", ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i+1:]
+	}
+	// The colon of the registry port is not a tag separator
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+
+	for _, img := range images {
+		if img == nil || img.Name != name {
+			continue
+		}
+		if img.NewName != "" {
+			name = img.NewName
+		}
+		if img.NewTag != "" {
+			tag, digest = img.NewTag, ""
+		}
+		if img.Digest != "" {
+			tag, digest = "", img.Digest
+		}
+		break
+	}
+
+	if tag != "" {
+		name += ":" + tag
+	}
+	if digest != "" {
+		name += "@" + digest
+	}
+	return name
+}
+
+// resolveKustomizePath joins the path relative to the kustomization directory
+// Paths outside the repository can't be read, so they are rejected.
+func resolveKustomizePath(dir, entry string) (string, error) {
+	p := path.Join(dir, entry)
+	if p == ".." || strings.HasPrefix(p, "../") || path.IsAbs(entry) {
+		return "", fmt.Errorf("%s is outside the repository", entry)
+	}
+	return p, nil
+}
+
+// isRemoteKustomizeResource reports whether the resource is a URL or a git repository
+func isRemoteKustomizeResource(entry string) bool {
+	return strings.Contains(entry, "://") || strings.HasPrefix(entry, "github.com/") || strings.HasPrefix(entry, "git@") || strings.Contains(entry, "?ref=")
+}
+
+func isYAMLFile(p string) bool {
+	switch path.Ext(p) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_renderKustomization(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"base/kustomization.yaml": "resources:\n- deployment.yaml\n- https://github.com/p1ass/manifests/base?ref=v1\n",
+		"base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: mikku
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: ghcr.io/p1ass/mikku:latest
+      containers:
+      - name: mikku
+        image: ghcr.io/p1ass/mikku
+      - name: proxy
+        image: localhost:5000/envoy:v1.20
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: mikku
+`,
+		"overlays/prod/kustomization.yaml": `resources:
+- ../../base
+patchesStrategicMerge:
+- replicas.yaml
+images:
+- name: ghcr.io/p1ass/mikku
+  newTag: v1.1.0
+- name: localhost:5000/envoy
+  newName: envoyproxy/envoy
+`,
+		"overlays/prod/replicas.yaml":          "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: mikku\nspec:\n  replicas: 3\n",
+		"overlays/cycle/kustomization.yaml":    "resources:\n- ../loop\n",
+		"overlays/loop/kustomization.yaml":     "resources:\n- ../cycle\n",
+		"overlays/missing/kustomization.yaml":  "resources:\n- deployment.yaml\n",
+		"overlays/outside/kustomization.yaml":  "resources:\n- ../../../base\n",
+		"overlays/badpatch/kustomization.yaml": "resources:\n- ../../base\npatches:\n- path: patch.yaml\n",
+		"overlays/badpatch/patch.yaml":         "spec: [\n",
+	}
+
+	tests := []struct {
+		name    string
+		overlay string
+		want    []*kubernetesResource
+		wantErr error
+	}{
+		{
+			name:    "render overlay with base",
+			overlay: "overlays/prod",
+			want: []*kubernetesResource{
+				{
+					source: "base/deployment.yaml#0",
+					object: map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata":   map[string]interface{}{"name": "mikku"},
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"initContainers": []interface{}{
+										map[string]interface{}{"name": "migrate", "image": "ghcr.io/p1ass/mikku:v1.1.0"},
+									},
+									"containers": []interface{}{
+										map[string]interface{}{"name": "mikku", "image": "ghcr.io/p1ass/mikku:v1.1.0"},
+										map[string]interface{}{"name": "proxy", "image": "envoyproxy/envoy:v1.20"},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					source: "base/deployment.yaml#1",
+					object: map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Service",
+						"metadata":   map[string]interface{}{"name": "mikku"},
+					},
+				},
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "cycle",
+			overlay: "overlays/cycle",
+			want:    nil,
+			wantErr: errKustomizationCycle,
+		},
+		{
+			name:    "missing resource",
+			overlay: "overlays/missing",
+			want:    nil,
+			wantErr: errFileNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderKustomization(context.Background(), readFiles(files), tt.overlay)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("renderKustomization() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want, cmp.AllowUnexported(kubernetesResource{})) {
+				t.Errorf("renderKustomization() diff=%s", cmp.Diff(got, tt.want, cmp.AllowUnexported(kubernetesResource{})))
+			}
+		})
+	}
+
+	for _, overlay := range []string{"overlays/outside", "overlays/badpatch"} {
+		if _, err := renderKustomization(context.Background(), readFiles(files), overlay); err == nil {
+			t.Errorf("renderKustomization(%s) error = nil, want error", overlay)
+		}
+	}
+}
+
+func Test_transformKustomizeImage(t *testing.T) {
+	t.Parallel()
+
+	images := []*kustomizationImage{
+		{Name: "ghcr.io/p1ass/mikku", NewTag: "v1.1.0"},
+		{Name: "redis", Digest: "sha256:abc"},
+		{Name: "localhost:5000/envoy", NewName: "envoyproxy/envoy"},
+	}
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "ghcr.io/p1ass/mikku:v1.0.0", want: "ghcr.io/p1ass/mikku:v1.1.0"},
+		{image: "ghcr.io/p1ass/mikku@sha256:old", want: "ghcr.io/p1ass/mikku:v1.1.0"},
+		{image: "redis:6.0", want: "redis@sha256:abc"},
+		{image: "localhost:5000/envoy:v1.20", want: "envoyproxy/envoy:v1.20"},
+		{image: "nginx:1.21", want: "nginx:1.21"},
+	}
+	for _, tt := range tests {
+		if got := transformKustomizeImage(tt.image, images); got != tt.want {
+			t.Errorf("transformKustomizeImage(%s) = %s, want %s", tt.image, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 5
+	defaultBaseDelay  = 1 * time.Second
+	// defaultMaxDelay is the longest time to wait before a retry.
+	// If GitHub asks to wait longer, the response is returned without retrying.
+	defaultMaxDelay = 1 * time.Minute
+)
+
+// retryTransport is a http.RoundTripper which retries idempotent requests
+// on server errors and rate limits with exponential backoff
+type retryTransport struct {
+	base http.RoundTripper
+	// requestTimeout is the timeout of each attempt. If it is zero, there is no timeout.
+	requestTimeout time.Duration
+	maxRetries     int
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+	now            func() time.Time
+
+	// blockedUntil is shared by all requests so that concurrent callers don't hit the rate limit again
+	mu           sync.Mutex
+	blockedUntil time.Time
+}
+
+func newRetryTransport(base http.RoundTripper, requestTimeout time.Duration) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:           base,
+		requestTimeout: requestTimeout,
+		maxRetries:     defaultMaxRetries,
+		baseDelay:      defaultBaseDelay,
+		maxDelay:       defaultMaxDelay,
+		now:            time.Now,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := sleepContext(req.Context(), t.blockedDelay()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTrip(req)
+		if attempt >= t.maxRetries || !isIdempotent(req) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := t.retryDelay(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain the body to reuse the connection
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// blockedDelay returns how long to wait until the rate limit is reset
+func (t *retryTransport) blockedDelay() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.blockedUntil.Sub(t.now())
+}
+
+// block blocks all requests sent through the transport for the delay
+func (t *retryTransport) block(delay time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := t.now().Add(delay); until.After(t.blockedUntil) {
+		t.blockedUntil = until
+	}
+}
+
+// sleepContext waits for the delay unless the context is done
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// roundTrip sends the request once applying the request timeout
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if t.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.requestTimeout)
+	}
+
+	r := req.Clone(ctx)
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The body is read after RoundTrip returns, so cancel the context when it is closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryDelay returns how long to wait before the next attempt and whether the request should be retried
+func (t *retryTransport) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if err != nil {
+		return t.backoff(attempt), true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusForbidden:
+		// Secondary rate limits have Retry-After header
+		if after := resp.Header.Get("Retry-After"); after != "" {
+			seconds, err := strconv.Atoi(after)
+			if err != nil {
+				return 0, false
+			}
+			return t.limitDelay(time.Duration(seconds) * time.Second)
+		}
+		// Primary rate limits are reset at X-RateLimit-Reset
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return t.limitDelay(time.Unix(reset, 0).Sub(t.now()))
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || isSecondaryRateLimit(resp) {
+			return t.backoff(attempt), true
+		}
+		return 0, false
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return t.backoff(attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// limitDelay gives up retrying if the rate limit is reset too late
+// Otherwise, the other requests also wait until the rate limit is reset.
+func (t *retryTransport) limitDelay(delay time.Duration) (time.Duration, bool) {
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > t.maxDelay {
+		return 0, false
+	}
+	t.block(delay)
+	return delay, true
+}
+
+// backoff returns the exponential backoff delay with jitter
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << uint(attempt)
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isSecondaryRateLimit reports whether the response is a secondary rate limit error without Retry-After header
+// The response body can be read again after calling it.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	b, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(bytes.ToLower(b), []byte("secondary rate limit"))
+}
+
+type idempotentKey struct{}
+
+// withIdempotent marks requests with the context as idempotent even if the method is not
+// Ex. GraphQL queries are sent by POST.
+func withIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent reports whether the request can be sent twice safely
+func isIdempotent(req *http.Request) bool {
+	if idempotent, ok := req.Context().Value(idempotentKey{}).(bool); ok && idempotent {
+		return true
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
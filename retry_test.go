@@ -0,0 +1,248 @@
+package mikku
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+func TestRetryTransport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// failures returns a failure response for the n-th request. If it returns false, the request succeeds.
+		failures     func(w http.ResponseWriter, n int32) bool
+		method       string
+		wantAttempts int32
+		wantStatus   int
+	}{
+		{
+			name: "retry bad gateway",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				if n <= 2 {
+					w.WriteHeader(http.StatusBadGateway)
+					return true
+				}
+				return false
+			},
+			method:       http.MethodGet,
+			wantAttempts: 3,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name: "retry secondary rate limit with Retry-After",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				if n == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusForbidden)
+					return true
+				}
+				return false
+			},
+			method:       http.MethodGet,
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name: "retry secondary rate limit without Retry-After",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				if n == 1 {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+					return true
+				}
+				return false
+			},
+			method:       http.MethodGet,
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name: "retry after rate limit reset",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				if n == 1 {
+					w.Header().Set("X-RateLimit-Remaining", "0")
+					w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Unix(), 10))
+					w.WriteHeader(http.StatusForbidden)
+					return true
+				}
+				return false
+			},
+			method:       http.MethodGet,
+			wantAttempts: 2,
+			wantStatus:   http.StatusOK,
+		},
+		{
+			name: "don't wait for rate limit reset too late",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+				return true
+			},
+			method:       http.MethodGet,
+			wantAttempts: 1,
+			wantStatus:   http.StatusForbidden,
+		},
+		{
+			name: "don't retry forbidden",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"message":"Resource not accessible by integration"}`))
+				return true
+			},
+			method:       http.MethodGet,
+			wantAttempts: 1,
+			wantStatus:   http.StatusForbidden,
+		},
+		{
+			name: "don't retry non-idempotent request",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			},
+			method:       http.MethodPost,
+			wantAttempts: 1,
+			wantStatus:   http.StatusBadGateway,
+		},
+		{
+			name: "give up after max retries",
+			failures: func(w http.ResponseWriter, n int32) bool {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			},
+			method:       http.MethodPut,
+			wantAttempts: 4,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if tt.failures(w, n) {
+					return
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			transport := newRetryTransport(http.DefaultTransport, 0)
+			transport.maxRetries = 3
+			transport.baseDelay = time.Millisecond
+			transport.now = func() time.Time { return now }
+
+			req, err := http.NewRequest(tt.method, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("retryTransport.RoundTrip() error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("retryTransport.RoundTrip() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("retryTransport.RoundTrip() attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransport_withGitHubClient(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/test-owner/test-repo/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"number":1,"merged_at":"2019-01-02T00:00:00Z","updated_at":"2019-01-02T00:00:00Z"}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	transport := newRetryTransport(http.DefaultTransport, time.Second)
+	transport.baseDelay = time.Millisecond
+	client := github.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	s := newGitHubClient("test-owner", gitHubClients{repositories: client.Repositories, pullRequests: client.PullRequests})
+	prs, err := s.getMergedPRsAfter(context.Background(), "test-repo", time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("githubClient.getMergedPRsAfter() error = %v", err)
+	}
+	if len(prs) != 1 {
+		t.Errorf("githubClient.getMergedPRsAfter() = %d PRs, want 1", len(prs))
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestRetryTransport_cancel(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	transport := newRetryTransport(http.DefaultTransport, 0)
+	transport.baseDelay = time.Hour
+	transport.maxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.RoundTrip(req); err != context.DeadlineExceeded {
+		t.Errorf("retryTransport.RoundTrip() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRetryTransport_idempotentPost(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	transport := newRetryTransport(http.DefaultTransport, 0)
+	transport.baseDelay = time.Millisecond
+
+	s := &graphqlService{client: &http.Client{Transport: transport}, endpoint: server.URL}
+	if err := s.Query(context.Background(), "query { viewer }", nil, &map[string]interface{}{}); err != nil {
+		t.Fatalf("graphqlService.Query() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
@@ -0,0 +1,283 @@
+package mikku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// kubernetesSchemasURL is the repository which the schema cache is copied from
+const kubernetesSchemasURL = "https://github.com/yannh/kubernetes-json-schema"
+
+var (
+	errSchemaNotCached = errors.New("kubernetes schemas are not cached")
+
+	kubernetesVersionReg = regexp.MustCompile(`^v?[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+)
+
+// kubernetesSchemas validates resources against the JSON schemas converted from Kubernetes OpenAPI
+// The schemas are read from a local cache laid out like github.com/yannh/kubernetes-json-schema,
+// such as v1.27.3-standalone-strict/deployment-apps-v1.json.
+type kubernetesSchemas struct {
+	dir string
+	// cache is the schemas which have been read. A nil schema means the kind has no schema such as custom resources.
+	cache map[string]map[string]interface{}
+}
+
+// loadKubernetesSchemas finds the schemas of the Kubernetes version in the cache
+func loadKubernetesSchemas(vc *ValidationConfig) (*kubernetesSchemas, error) {
+	root := vc.SchemaDir
+	if root == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("find cache directory: %w", err)
+		}
+		root = filepath.Join(cacheDir, "mikku", "schemas")
+	}
+
+	version := "v" + strings.TrimPrefix(vc.KubernetesVersion, "v")
+	// Strict schemas reject unknown fields, so they are preferred
+	for _, suffix := range []string{"-standalone-strict", "-standalone"} {
+		dir := filepath.Join(root, version+suffix)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return &kubernetesSchemas{dir: dir, cache: map[string]map[string]interface{}{}}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s in %s: %w: copy %s-standalone-strict of %s into the directory",
+		version, root, errSchemaNotCached, version, kubernetesSchemasURL)
+}
+
+// validate returns the violations of the resource
+// Resources without schemas are only checked for apiVersion, kind and metadata.name. Lists such as `kind: List` have no name.
+func (s *kubernetesSchemas) validate(obj map[string]interface{}) ([]string, error) {
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	if apiVersion == "" || kind == "" {
+		return []string{"apiVersion and kind should be set"}, nil
+	}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if !strings.HasSuffix(kind, "List") && (metadata == nil || metadata["name"] == nil) {
+		return []string{"metadata.name should be set"}, nil
+	}
+	if s == nil {
+		return nil, nil
+	}
+
+	schema, err := s.schema(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	if schema == nil {
+		return nil, nil
+	}
+	return validateJSONSchema(schema, schema, obj, kind), nil
+}
+
+// schema returns the schema of the kind. Ex. apps/v1 Deployment → deployment-apps-v1.json
+func (s *kubernetesSchemas) schema(apiVersion, kind string) (map[string]interface{}, error) {
+	name := strings.ToLower(kind)
+	if i := strings.Index(apiVersion, "/"); i >= 0 {
+		group := strings.SplitN(apiVersion[:i], ".", 2)[0]
+		name += "-" + group + "-" + apiVersion[i+1:]
+	} else {
+		name += "-" + apiVersion
+	}
+	if schema, ok := s.cache[name]; ok {
+		return schema, nil
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, name+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.cache[name] = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("%s.json: parse schema: %w", name, err)
+	}
+	s.cache[name] = schema
+	return schema, nil
+}
+
+// validateJSONSchema validates the value against the subset of JSON Schema used by Kubernetes schemas
+// It supports type, enum, properties, required, additionalProperties, items, allOf, anyOf, oneOf and local $ref.
+func validateJSONSchema(schema, root map[string]interface{}, value interface{}, at string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := resolveJSONSchemaRef(root, ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", at, err)}
+		}
+		return validateJSONSchema(resolved, root, value, at)
+	}
+
+	if types := jsonSchemaTypes(schema["type"]); len(types) > 0 && !matchesJSONSchemaType(types, value) {
+		return []string{fmt.Sprintf("%s: should be %s", at, strings.Join(types, " or "))}
+	}
+	// Nullable fields are allowed to be empty without any further checks
+	if value == nil {
+		return nil
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsJSONValue(enum, value) {
+		return []string{fmt.Sprintf("%s: should be one of %v", at, enum)}
+	}
+
+	var violations []string
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		subs, ok := schema[key].([]interface{})
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, sub := range subs {
+			if s, ok := sub.(map[string]interface{}); ok && len(validateJSONSchema(s, root, value, at)) == 0 {
+				matched++
+			}
+		}
+		if (key == "allOf" && matched != len(subs)) || (key == "anyOf" && matched == 0) || (key == "oneOf" && matched != 1) {
+			violations = append(violations, fmt.Sprintf("%s: doesn't match %s", at, key))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		violations = append(violations, validateJSONSchemaObject(schema, root, v, at)...)
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				violations = append(violations, validateJSONSchema(items, root, item, fmt.Sprintf("%s[%d]", at, i))...)
+			}
+		}
+	}
+	return violations
+}
+
+func validateJSONSchemaObject(schema, root map[string]interface{}, obj map[string]interface{}, at string) []string {
+	var violations []string
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if key, _ := r.(string); key != "" {
+				if _, ok := obj[key]; !ok {
+					violations = append(violations, fmt.Sprintf("%s: %s is required", at, key))
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	// Keep the order of the violations stable
+	sort.Strings(keys)
+	for _, key := range keys {
+		if prop, ok := properties[key].(map[string]interface{}); ok {
+			violations = append(violations, validateJSONSchema(prop, root, obj[key], at+"."+key)...)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, fmt.Sprintf("%s: unknown field %s", at, key))
+			}
+		case map[string]interface{}:
+			violations = append(violations, validateJSONSchema(additional, root, obj[key], at+"."+key)...)
+		}
+	}
+	return violations
+}
+
+// resolveJSONSchemaRef resolves the reference in the same document. Ex. #/definitions/io.k8s.api.core.v1.Container
+func resolveJSONSchemaRef(root map[string]interface{}, ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref: %s", ref)
+	}
+	var node interface{} = root
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref not found: %s", ref)
+		}
+		node = m[strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")]
+	}
+	schema, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref not found: %s", ref)
+	}
+	return schema, nil
+}
+
+func jsonSchemaTypes(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// matchesJSONSchemaType reports whether the value decoded from YAML is one of the types
+func matchesJSONSchemaType(types []string, value interface{}) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case string, time.Time:
+			// Unquoted timestamps are decoded as time.Time
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case int, int64, uint64:
+			if t == "integer" || t == "number" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsJSONValue(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package mikku
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_validateJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+  "type": "object",
+  "required": ["name"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string"},
+    "port": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+    "protocol": {"type": ["string", "null"], "enum": ["TCP", "UDP", null]},
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "containers": {"type": "array", "items": {"$ref": "#/definitions/container"}}
+  },
+  "definitions": {
+    "container": {"type": "object", "required": ["image"], "properties": {"image": {"type": "string"}}}
+  }
+}`), &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value map[string]interface{}
+		want  []string
+	}{
+		{
+			name: "valid",
+			value: map[string]interface{}{
+				"name":       "mikku",
+				"port":       8080,
+				"protocol":   nil,
+				"labels":     map[string]interface{}{"app": "mikku"},
+				"containers": []interface{}{map[string]interface{}{"image": "ghcr.io/p1ass/mikku:v1.1.0"}},
+			},
+			want: nil,
+		},
+		{
+			name:  "int or string",
+			value: map[string]interface{}{"name": "mikku", "port": "http"},
+			want:  nil,
+		},
+		{
+			name: "violations",
+			value: map[string]interface{}{
+				"port":       true,
+				"protocol":   "SCTP",
+				"labels":     map[string]interface{}{"replicas": 3},
+				"containers": []interface{}{map[string]interface{}{"name": "mikku"}},
+				"unknown":    "value",
+			},
+			want: []string{
+				"Test: name is required",
+				"Test.containers[0]: image is required",
+				"Test.labels.replicas: should be string",
+				"Test.port: doesn't match oneOf",
+				"Test.protocol: should be one of [TCP UDP <nil>]",
+				"Test: unknown field unknown",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateJSONSchema(schema, schema, tt.value, "Test")
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("validateJSONSchema() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
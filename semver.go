@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/google/go-github/v32/github"
 )
 
 const (
@@ -117,3 +119,64 @@ func createSemanticVersion(versions []int) string {
 
 	return sm
 }
+
+var (
+	breakingTitleReg = regexp.MustCompile(`^\w+(\([^)]*\))?!:`)
+	featureTitleReg  = regexp.MustCompile(`^feat(\([^)]*\))?:`)
+)
+
+// inferBumpType suggests the bump type from the titles, bodies and labels of the pull requests
+// It follows Conventional Commits. If there is no pull request, it returns 0.
+func inferBumpType(prs []*github.PullRequest) bumpType {
+	if len(prs) == 0 {
+		return 0
+	}
+
+	bt := patch
+	for _, pr := range prs {
+		switch prBumpType(pr) {
+		case major:
+			return major
+		case minor:
+			bt = minor
+		}
+	}
+	return bt
+}
+
+// prBumpType returns the bump type which the pull request requires
+func prBumpType(pr *github.PullRequest) bumpType {
+	title := strings.TrimSpace(pr.GetTitle())
+	switch {
+	case breakingTitleReg.MatchString(title) || strings.Contains(pr.GetBody(), "BREAKING CHANGE") || hasLabel(pr, "breaking-change", "breaking", "major"):
+		return major
+	case featureTitleReg.MatchString(title) || hasLabel(pr, "feature", "enhancement", "minor"):
+		return minor
+	default:
+		return patch
+	}
+}
+
+func hasLabel(pr *github.PullRequest, names ...string) bool {
+	for _, l := range pr.Labels {
+		for _, name := range names {
+			if strings.EqualFold(l.GetName(), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (bt bumpType) String() string {
+	switch bt {
+	case major:
+		return "major"
+	case minor:
+		return "minor"
+	case patch:
+		return "patch"
+	default:
+		return ""
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
 )
 
 func Test_validSemver(t *testing.T) {
@@ -235,3 +236,51 @@ func Test_determineNewTag(t *testing.T) {
 		})
 	}
 }
+
+func Test_inferBumpType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		prs  []*github.PullRequest
+		want bumpType
+	}{
+		{
+			name: "no pull requests",
+			prs:  nil,
+			want: 0,
+		},
+		{
+			name: "fixes",
+			prs:  []*github.PullRequest{{Title: github.String("fix: typo")}, {Title: github.String("Update README")}},
+			want: patch,
+		},
+		{
+			name: "feature title",
+			prs:  []*github.PullRequest{{Title: github.String("fix: typo")}, {Title: github.String("feat(cli): add flag")}},
+			want: minor,
+		},
+		{
+			name: "feature label",
+			prs:  []*github.PullRequest{{Title: github.String("Add flag"), Labels: []*github.Label{{Name: github.String("Enhancement")}}}},
+			want: minor,
+		},
+		{
+			name: "breaking title",
+			prs:  []*github.PullRequest{{Title: github.String("feat(cli)!: remove flag")}},
+			want: major,
+		},
+		{
+			name: "breaking body",
+			prs:  []*github.PullRequest{{Title: github.String("Remove flag"), Body: github.String("BREAKING CHANGE: --foo is removed")}},
+			want: major,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inferBumpType(tt.prs); got != tt.want {
+				t.Errorf("inferBumpType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package mikku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	statusFormatTable = "table"
+	statusFormatJSON  = "json"
+)
+
+var (
+	errInvalidStatusFormat = errors.New("format should be table or json")
+	errStatusFailed        = errors.New("failed to get status of some repositories")
+)
+
+// StatusOptions represents optional settings of `mikku status` command
+type StatusOptions struct {
+	// Format is the output format. table or json
+	Format string
+	// Concurrency is the number of repositories checked at the same time
+	Concurrency int
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+}
+
+// repositoryStatus represents the unreleased changes of the repository
+type repositoryStatus struct {
+	Repository string `json:"repository"`
+	// CurrentTag is empty if the repository has never been released
+	CurrentTag             string     `json:"currentTag,omitempty"`
+	PublishedAt            *time.Time `json:"publishedAt,omitempty"`
+	UnreleasedPullRequests int        `json:"unreleasedPullRequests"`
+	// SuggestedBump is inferred from the unreleased pull requests
+	SuggestedBump string `json:"suggestedBump,omitempty"`
+	SuggestedTag  string `json:"suggestedTag,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// Status is the entry point of `mikku status` command
+func Status(ctx context.Context, repos []string, opts StatusOptions) error {
+	if opts.Format != statusFormatTable && opts.Format != statusFormatJSON {
+		return fmt.Errorf("status: %w", errInvalidStatusFormat)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("status: %w", err)
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	statuses := collectStatuses(ctx, cfg, svc, repos, opts.Concurrency)
+
+	if opts.Format == statusFormatJSON {
+		if err := printStatusJSON(os.Stdout, statuses); err != nil {
+			return fmt.Errorf("status: %w", err)
+		}
+	} else {
+		printStatusTable(os.Stdout, statuses, time.Now())
+	}
+
+	for _, st := range statuses {
+		if st.Error != "" {
+			return errStatusFailed
+		}
+	}
+	return nil
+}
+
+// collectStatuses gets the status of each repository with the bounded number of workers
+// The statuses are returned in the same order as the repositories.
+func collectStatuses(ctx context.Context, cfg *Config, svc *githubClient, repos []string, concurrency int) []*repositoryStatus {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	statuses := make([]*repositoryStatus, len(repos))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			st, err := getRepositoryStatus(ctx, cfg, svc, repo)
+			if err != nil {
+				st = &repositoryStatus{Repository: repo, Error: err.Error()}
+			}
+			statuses[i] = st
+		}(i, repo)
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+// getRepositoryStatus gets the latest release and the pull requests merged after it
+// The pull requests are filtered in the same way as the release, so excluded ones are neither counted nor used for the suggestion.
+func getRepositoryStatus(ctx context.Context, cfg *Config, svc *githubClient, repo string) (*repositoryStatus, error) {
+	st := &repositoryStatus{Repository: repo}
+
+	after, currentTag, err := svc.getLastPublishedAndCurrentTag(ctx, repo)
+	if err != nil && !errors.Is(err, errReleaseNotFound) {
+		return nil, fmt.Errorf("failed to get latest published date or tag: %w", err)
+	}
+	if err == nil {
+		st.CurrentTag = currentTag
+		st.PublishedAt = &after
+	}
+
+	prs, err := svc.getMergedPRsAfter(ctx, repo, after)
+	if err != nil {
+		return nil, fmt.Errorf("get pull requests: %w", err)
+	}
+	prs, dependencyUpdates := filterPRs(excludeReleasePullRequests(prs), cfg.repository(repo).Changelog)
+	prs = append(prs, dependencyUpdates...)
+	st.UnreleasedPullRequests = len(prs)
+
+	bt := inferBumpType(prs)
+	st.SuggestedBump = bt.String()
+	// The first release needs the explicit version
+	if bt != 0 && validSemver(st.CurrentTag) {
+		newTag, err := bumpVersion(semVerReg.FindString(st.CurrentTag), bt)
+		if err != nil {
+			return nil, fmt.Errorf("bump version: %w", err)
+		}
+		st.SuggestedTag = newTag
+	}
+	return st, nil
+}
+
+func printStatusJSON(w io.Writer, statuses []*repositoryStatus) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(statuses); err != nil {
+		return fmt.Errorf("encode statuses: %w", err)
+	}
+	return nil
+}
+
+// printStatusTable prints the statuses as a table
+// The age of the current tag is calculated from now.
+func printStatusTable(w io.Writer, statuses []*repositoryStatus, now time.Time) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "REPOSITORY\tTAG\tAGE\tUNRELEASED\tSUGGESTED")
+	for _, st := range statuses {
+		if st.Error != "" {
+			_, _ = fmt.Fprintf(tw, "%s\t-\t-\t-\terror: %s\n", st.Repository, st.Error)
+			continue
+		}
+
+		tag, age := "-", "-"
+		if st.CurrentTag != "" {
+			tag = st.CurrentTag
+			age = formatAge(now.Sub(*st.PublishedAt))
+		}
+		suggested := "-"
+		if st.SuggestedTag != "" {
+			suggested = st.SuggestedTag + " (" + st.SuggestedBump + ")"
+		} else if st.SuggestedBump != "" {
+			suggested = st.SuggestedBump
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", st.Repository, tag, age, st.UnreleasedPullRequests, suggested)
+	}
+	_ = tw.Flush()
+}
+
+// formatAge formats the duration in the largest unit of days, hours or minutes. Ex. 3d
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	case d >= time.Hour:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+}
@@ -0,0 +1,172 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_getRepositoryStatus(t *testing.T) {
+	t.Parallel()
+
+	publishedAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cfg      *Config
+		injector func(*MockgitHubRepositoriesClient, *MockgitHubPullRequestsClient)
+		want     *repositoryStatus
+		wantErr  bool
+	}{
+		{
+			name: "unreleased pull requests",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient) {
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").Return(&github.RepositoryRelease{
+					TagName:     github.String("v1.2.3"),
+					PublishedAt: &github.Timestamp{Time: publishedAt},
+				}, nil, nil)
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return([]*github.PullRequest{
+					{
+						Title:     github.String("feat: add status command"),
+						UpdatedAt: timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+						MergedAt:  timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+					},
+					{
+						Title:     github.String("fix: typo"),
+						UpdatedAt: timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+						MergedAt:  timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+					},
+				}, &github.Response{}, nil)
+			},
+			want: &repositoryStatus{
+				Repository:             "test-repo",
+				CurrentTag:             "v1.2.3",
+				PublishedAt:            &publishedAt,
+				UnreleasedPullRequests: 2,
+				SuggestedBump:          "minor",
+				SuggestedTag:           "v1.3.0",
+			},
+			wantErr: false,
+		},
+		{
+			name: "excluded pull requests are not counted",
+			cfg: &Config{Repositories: map[string]*RepositoryConfig{
+				"test-repo": {Changelog: &ChangelogConfig{
+					Exclude:           &PullRequestRule{Labels: []string{"skip-changelog"}},
+					DependencyUpdates: &PullRequestRule{Authors: []string{"dependabot[bot]"}},
+				}},
+			}},
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient) {
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").Return(&github.RepositoryRelease{
+					TagName:     github.String("v1.2.3"),
+					PublishedAt: &github.Timestamp{Time: publishedAt},
+				}, nil, nil)
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return([]*github.PullRequest{
+					{
+						Title:     github.String("feat: add internal tool"),
+						Labels:    []*github.Label{{Name: github.String("skip-changelog")}},
+						UpdatedAt: timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+						MergedAt:  timeToPointer(time.Date(2019, 1, 3, 0, 0, 0, 0, time.UTC)),
+					},
+					{
+						Title:     github.String("Bump golang.org/x/mod"),
+						User:      &github.User{Login: github.String("dependabot[bot]")},
+						UpdatedAt: timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+						MergedAt:  timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+					},
+				}, &github.Response{}, nil)
+			},
+			want: &repositoryStatus{
+				Repository:             "test-repo",
+				CurrentTag:             "v1.2.3",
+				PublishedAt:            &publishedAt,
+				UnreleasedPullRequests: 1,
+				SuggestedBump:          "patch",
+				SuggestedTag:           "v1.2.4",
+			},
+			wantErr: false,
+		},
+		{
+			name: "never released",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient) {
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").
+					Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found"))
+				prCli.EXPECT().List(gomock.Any(), "test-owner", "test-repo", gomock.Any()).Return([]*github.PullRequest{
+					{
+						Title:     github.String("Initial implementation"),
+						UpdatedAt: timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+						MergedAt:  timeToPointer(time.Date(2019, 1, 2, 0, 0, 0, 0, time.UTC)),
+					},
+				}, &github.Response{}, nil)
+			},
+			want: &repositoryStatus{
+				Repository:             "test-repo",
+				UnreleasedPullRequests: 1,
+				SuggestedBump:          "patch",
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed to get latest release",
+			injector: func(repoCli *MockgitHubRepositoriesClient, prCli *MockgitHubPullRequestsClient) {
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").
+					Return(nil, &github.Response{Response: &http.Response{StatusCode: http.StatusInternalServerError}}, errors.New("server error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			prCli := NewMockgitHubPullRequestsClient(ctrl)
+			tt.injector(repoCli, prCli)
+
+			svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, pullRequests: prCli})
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &Config{}
+			}
+			got, err := getRepositoryStatus(context.Background(), cfg, svc, "test-repo")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getRepositoryStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("getRepositoryStatus() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_printStatusTable(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, 1, 4, 12, 0, 0, 0, time.UTC)
+	publishedAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buf := &bytes.Buffer{}
+	printStatusTable(buf, []*repositoryStatus{
+		{Repository: "service-a", CurrentTag: "v1.2.3", PublishedAt: &publishedAt, UnreleasedPullRequests: 2, SuggestedBump: "minor", SuggestedTag: "v1.3.0"},
+		{Repository: "service-b", UnreleasedPullRequests: 1, SuggestedBump: "patch"},
+		{Repository: "service-c", Error: "server error"},
+	}, now)
+
+	want := `REPOSITORY  TAG     AGE  UNRELEASED  SUGGESTED
+service-a   v1.2.3  3d   2           v1.3.0 (minor)
+service-b   -       -    1           patch
+service-c   -       -    -           error: server error
+`
+	if buf.String() != want {
+		t.Errorf("printStatusTable() diff=%s", cmp.Diff(buf.String(), want))
+	}
+}
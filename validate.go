@@ -0,0 +1,75 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errInvalidManifests = errors.New("edited manifests are invalid")
+
+// validateManifestChanges validates the manifests edited by the changes before they are committed
+// Every changed file should be valid multi-document YAML. Only if manifest.validation is set, the kustomize overlays are rendered
+// with the changes, and the resources are validated against the Kubernetes schemas if its kubernetesVersion is set.
+func validateManifestChanges(ctx context.Context, read readFileFunc, mc *ManifestConfig, changes []*fileChange) error {
+	var violations []string
+	for _, c := range changes {
+		if _, err := decodeYAMLDocuments(c.content); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", c.path, err))
+		}
+	}
+	// The overlays refer to the broken files, so rendering them only repeats the errors
+	if len(violations) > 0 {
+		return fmt.Errorf("%w:\n%s", errInvalidManifests, strings.Join(violations, "\n"))
+	}
+	if mc.Validation == nil {
+		return nil
+	}
+
+	var schemas *kubernetesSchemas
+	if mc.Validation.KubernetesVersion != "" {
+		s, err := loadKubernetesSchemas(mc.Validation)
+		if err != nil {
+			return fmt.Errorf("load schemas: %w", err)
+		}
+		schemas = s
+	}
+
+	edited := readEditedFiles(read, changes)
+	for _, overlay := range mc.Kustomize {
+		resources, err := renderKustomization(ctx, edited, overlay)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s: render: %v", overlay, err))
+			continue
+		}
+		for _, r := range resources {
+			errs, err := schemas.validate(r.object)
+			if err != nil {
+				return fmt.Errorf("%s: %w", r.source, err)
+			}
+			for _, e := range errs {
+				violations = append(violations, fmt.Sprintf("%s: %s", r.source, e))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("%w:\n%s", errInvalidManifests, strings.Join(violations, "\n"))
+	}
+	return nil
+}
+
+// readEditedFiles returns readFileFunc which reads the changed files from the changes instead of the repository
+func readEditedFiles(read readFileFunc, changes []*fileChange) readFileFunc {
+	edited := make(map[string]string, len(changes))
+	for _, c := range changes {
+		edited[c.path] = c.content
+	}
+	return func(ctx context.Context, path string) (string, error) {
+		if content, ok := edited[path]; ok {
+			return content, nil
+		}
+		return read(ctx, path)
+	}
+}
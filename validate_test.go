@@ -0,0 +1,154 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDeploymentSchema is a small part of the standalone-strict schema of apps/v1 Deployment
+const testDeploymentSchema = `{
+  "type": "object",
+  "required": ["apiVersion", "kind", "metadata"],
+  "additionalProperties": false,
+  "properties": {
+    "apiVersion": {"type": ["string", "null"]},
+    "kind": {"type": ["string", "null"]},
+    "metadata": {"type": "object", "properties": {"name": {"type": ["string", "null"]}}},
+    "spec": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "replicas": {"type": ["integer", "null"]},
+        "template": {
+          "type": "object",
+          "properties": {
+            "spec": {
+              "type": "object",
+              "properties": {
+                "containers": {"type": "array", "items": {"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "image": {"type": "string"}}}}
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+func Test_validateManifestChanges(t *testing.T) {
+	t.Parallel()
+
+	schemaDir := t.TempDir()
+	writeTestFile(t, filepath.Join(schemaDir, "v1.27.3-standalone-strict", "deployment-apps-v1.json"), testDeploymentSchema)
+
+	files := map[string]string{
+		"base/kustomization.yaml":          "resources:\n- deployment.yaml\n- certificate.yaml\n",
+		"base/deployment.yaml":             "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: mikku\nspec:\n  replicas: 2\n  template:\n    spec:\n      containers:\n      - name: mikku\n        image: ghcr.io/p1ass/mikku\n",
+		"base/certificate.yaml":            "apiVersion: cert-manager.io/v1\nkind: Certificate\nmetadata:\n  name: mikku\nspec:\n  secretName: mikku-tls\n",
+		"overlays/prod/kustomization.yaml": "resources:\n- ../../base\nimages:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.0.0\n",
+	}
+	mc := &ManifestConfig{
+		Kustomize:  []string{"overlays/prod"},
+		Validation: &ValidationConfig{KubernetesVersion: "1.27.3", SchemaDir: schemaDir},
+	}
+
+	tests := []struct {
+		name    string
+		mc      *ManifestConfig
+		changes []*fileChange
+		want    []string
+		wantErr error
+	}{
+		{
+			name:    "valid",
+			mc:      mc,
+			changes: []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "resources:\n- ../../base\nimages:\n- name: ghcr.io/p1ass/mikku\n  newTag: v1.1.0\n"}},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name: "broken multi-document yaml",
+			mc:   mc,
+			changes: []*fileChange{
+				{path: "overlays/prod/kustomization.yaml", content: "resources:\n- ../../base\n"},
+				{path: "chart/values.yaml", content: "image:\n  tag: v1.1.0\n---\nimage: [\n"},
+			},
+			want:    []string{"chart/values.yaml: document 1: parse yaml"},
+			wantErr: errInvalidManifests,
+		},
+		{
+			name:    "schema violation",
+			mc:      mc,
+			changes: []*fileChange{{path: "base/deployment.yaml", content: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: mikku\nspec:\n  replicas: two\n  strategy: {}\n"}},
+			want: []string{
+				"base/deployment.yaml#0: Deployment.spec.replicas: should be integer or null",
+				"base/deployment.yaml#0: Deployment.spec: unknown field strategy",
+			},
+			wantErr: errInvalidManifests,
+		},
+		{
+			name:    "resource without name is rejected without schemas",
+			mc:      &ManifestConfig{Kustomize: []string{"overlays/prod"}, Validation: &ValidationConfig{}},
+			changes: []*fileChange{{path: "base/certificate.yaml", content: "apiVersion: cert-manager.io/v1\nkind: Certificate\nspec: {}\n"}},
+			want:    []string{"base/certificate.yaml#0: metadata.name should be set"},
+			wantErr: errInvalidManifests,
+		},
+		{
+			name:    "list without name",
+			mc:      mc,
+			changes: []*fileChange{{path: "base/certificate.yaml", content: "apiVersion: v1\nkind: List\nitems: []\n"}},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name: "overlays are not rendered without validation",
+			mc:   &ManifestConfig{Kustomize: []string{"overlays/prod"}},
+			changes: []*fileChange{
+				{path: "overlays/prod/kustomization.yaml", content: "resources:\n- ../../base\n- service.yaml\n"},
+				{path: "base/certificate.yaml", content: "apiVersion: cert-manager.io/v1\nkind: Certificate\nspec: {}\n"},
+			},
+			want:    nil,
+			wantErr: nil,
+		},
+		{
+			name:    "broken yaml is rejected without validation",
+			mc:      &ManifestConfig{Kustomize: []string{"overlays/prod"}},
+			changes: []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "resources: [\n"}},
+			want:    []string{"overlays/prod/kustomization.yaml: document 0: parse yaml"},
+			wantErr: errInvalidManifests,
+		},
+		{
+			name:    "render error",
+			mc:      mc,
+			changes: []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "resources:\n- ../../base\n- service.yaml\n"}},
+			want:    []string{"overlays/prod: render:"},
+			wantErr: errInvalidManifests,
+		},
+		{
+			name: "schemas of the version are not cached",
+			mc: &ManifestConfig{
+				Kustomize:  []string{"overlays/prod"},
+				Validation: &ValidationConfig{KubernetesVersion: "1.28.0", SchemaDir: schemaDir},
+			},
+			changes: []*fileChange{{path: "overlays/prod/kustomization.yaml", content: "resources:\n- ../../base\n"}},
+			want:    []string{"copy v1.28.0-standalone-strict of " + kubernetesSchemasURL},
+			wantErr: errSchemaNotCached,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateManifestChanges(context.Background(), readFiles(files), tt.mc, tt.changes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("validateManifestChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("validateManifestChanges() error = %v, want to contain %q", err, w)
+				}
+			}
+		})
+	}
+}
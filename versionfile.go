@@ -0,0 +1,80 @@
+package mikku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// errVersionNotFound represents error that the version does not found in the version file
+	errVersionNotFound = errors.New("version not found")
+)
+
+// generateVersionFileChanges returns the changes of version files bumped to the new tag
+// Files which already have the new version are skipped.
+func generateVersionFileChanges(ctx context.Context, svc *githubClient, repo string, vfs []*VersionFile, newTag string) ([]*fileChange, error) {
+	var changes []*fileChange
+	for _, vf := range vfs {
+		content, err := svc.getFile(ctx, repo, vf.Path, baseBranch)
+		if err != nil {
+			return nil, fmt.Errorf("get version file: %w", err)
+		}
+
+		updated, err := updateVersionFile(vf, content, newTag)
+		if err != nil {
+			return nil, fmt.Errorf("update %s: %w", vf.Path, err)
+		}
+		if updated == content {
+			continue
+		}
+		changes = append(changes, &fileChange{path: vf.Path, content: updated})
+	}
+	return changes, nil
+}
+
+// updateVersionFile replaces the version in the content with the new tag
+func updateVersionFile(vf *VersionFile, content string, newTag string) (string, error) {
+	if vf.Key != "" {
+		node, err := findYAMLScalar([]byte(content), vf.Key)
+		if err != nil {
+			return "", fmt.Errorf("find %s: %w", vf.Key, err)
+		}
+		updated, err := replaceYAMLScalar([]byte(content), node, versionLike(node.Value, newTag))
+		if err != nil {
+			return "", fmt.Errorf("replace %s: %w", vf.Key, err)
+		}
+		return string(updated), nil
+	}
+
+	reg, err := regexp.Compile(vf.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("compile pattern: %w", err)
+	}
+
+	matches := reg.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%s: %w", vf.Pattern, errVersionNotFound)
+	}
+
+	// Replace from the end so that the indices of the earlier matches are not shifted
+	for i := len(matches) - 1; i >= 0; i-- {
+		start, end := matches[i][2], matches[i][3]
+		if start < 0 {
+			continue
+		}
+		content = content[:start] + versionLike(content[start:end], newTag) + content[end:]
+	}
+	return content, nil
+}
+
+// versionLike formats the new tag in the same way as the old version
+// Ex. If the old version is `1.0.0`, `v1.0.1` is formatted to `1.0.1`
+func versionLike(old, newTag string) string {
+	if strings.HasPrefix(old, semVerPrefix) {
+		return newTag
+	}
+	return strings.TrimPrefix(newTag, semVerPrefix)
+}
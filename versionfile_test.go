@@ -0,0 +1,60 @@
+package mikku
+
+import (
+	"testing"
+)
+
+func Test_updateVersionFile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		vf      *VersionFile
+		content string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "package.json without prefix",
+			vf:      &VersionFile{Path: "package.json", Key: "version"},
+			content: "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\"\n}\n",
+			want:    "{\n  \"name\": \"app\",\n  \"version\": \"1.1.0\"\n}\n",
+		},
+		{
+			name:    "Chart.yaml appVersion with prefix",
+			vf:      &VersionFile{Path: "Chart.yaml", Key: "appVersion"},
+			content: "version: 0.1.0\nappVersion: v1.0.0\n",
+			want:    "version: 0.1.0\nappVersion: v1.1.0\n",
+		},
+		{
+			name:    "version.go by pattern",
+			vf:      &VersionFile{Path: "version.go", Pattern: `Version = "(.+)"`},
+			content: "package app\n\nconst Version = \"v1.0.0\"\n",
+			want:    "package app\n\nconst Version = \"v1.1.0\"\n",
+		},
+		{
+			name:    "pyproject.toml by pattern",
+			vf:      &VersionFile{Path: "pyproject.toml", Pattern: `(?m)^version = "(.+)"$`},
+			content: "[tool.poetry]\nname = \"app\"\nversion = \"1.0.0\"\n",
+			want:    "[tool.poetry]\nname = \"app\"\nversion = \"1.1.0\"\n",
+		},
+		{
+			name:    "pattern not matched",
+			vf:      &VersionFile{Path: "version.go", Pattern: `Version = "(.+)"`},
+			content: "package app\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateVersionFile(tt.vf, tt.content, "v1.1.0")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("updateVersionFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("updateVersionFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package mikku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	defaultEditor = "vi"
+	// prereleaseSuffix and the number are appended to the suggested version for pre-releases. Ex. v1.1.0-rc.1
+	prereleaseSuffix = "-rc."
+)
+
+var (
+	errReleaseAborted = errors.New("release was aborted")
+
+	prereleaseNumberReg = regexp.MustCompile(`^(v[0-9]+\.[0-9]+\.[0-9]+-[0-9A-Za-z.-]*?)([0-9]+)$`)
+)
+
+// releaseWizard asks the user to choose the version and edit the release body
+type releaseWizard struct {
+	in  *bufio.Reader
+	out io.Writer
+	// editor opens the file in the editor and waits until it is closed
+	editor func(path string) error
+}
+
+func newReleaseWizard() *releaseWizard {
+	return &releaseWizard{
+		in:     bufio.NewReader(os.Stdin),
+		out:    os.Stdout,
+		editor: runEditor,
+	}
+}
+
+// tagExistsFunc reports whether the tag exists in the repository
+type tagExistsFunc func(tag string) (bool, error)
+
+// versionCandidate represents a version the user can choose
+type versionCandidate struct {
+	label string
+	tag   string
+	// prs are the pull requests which require the bump type
+	prs       []*github.PullRequest
+	suggested bool
+}
+
+// versionCandidates returns major, minor, patch and prerelease versions of the current tag
+// The suggested version is inferred from the pull requests.
+func versionCandidates(currentTag string, prs []*github.PullRequest, exists tagExistsFunc) ([]*versionCandidate, error) {
+	base := semVerReg.FindString(currentTag)
+	if base == "" {
+		return nil, errInvalidSemanticVersioningTag
+	}
+
+	byType := map[bumpType][]*github.PullRequest{}
+	for _, pr := range prs {
+		bt := prBumpType(pr)
+		byType[bt] = append(byType[bt], pr)
+	}
+	suggested := inferBumpType(prs)
+
+	candidates := make([]*versionCandidate, 0, 4)
+	for _, bt := range []bumpType{major, minor, patch} {
+		tag, err := bumpVersion(base, bt)
+		if err != nil {
+			return nil, fmt.Errorf("bump version: %w", err)
+		}
+		candidates = append(candidates, &versionCandidate{label: bt.String(), tag: tag, prs: byType[bt], suggested: bt == suggested})
+	}
+
+	prerelease, err := nextPrerelease(currentTag, base, suggested, exists)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, &versionCandidate{label: "prerelease", tag: prerelease})
+	return candidates, nil
+}
+
+// nextPrerelease returns the first pre-release of the suggested version whose tag doesn't exist. Ex. v1.1.0-rc.3 if rc.1 and rc.2 exist
+// Published pre-releases are never the latest release, so the existing tags are probed instead of incrementing the current tag.
+// If the current tag is a pre-release, its number is incremented in the same way.
+func nextPrerelease(currentTag, base string, suggested bumpType, exists tagExistsFunc) (string, error) {
+	prefix, n := "", 0
+	if m := prereleaseNumberReg.FindStringSubmatch(currentTag); m != nil {
+		current, err := strconv.Atoi(m[2])
+		if err != nil {
+			return "", fmt.Errorf("convert string to int: %w", err)
+		}
+		prefix, n = m[1], current+1
+	} else {
+		if suggested == 0 {
+			suggested = patch
+		}
+		tag, err := bumpVersion(base, suggested)
+		if err != nil {
+			return "", fmt.Errorf("bump version: %w", err)
+		}
+		prefix, n = tag+prereleaseSuffix, 1
+	}
+
+	for ; ; n++ {
+		tag := prefix + strconv.Itoa(n)
+		ok, err := exists(tag)
+		if err != nil {
+			return "", fmt.Errorf("check tag %s: %w", tag, err)
+		}
+		if !ok {
+			return tag, nil
+		}
+	}
+}
+
+// chooseVersion shows the candidates and returns the version the user chose
+// If the current tag is not a semantic version, the user types the version.
+func (wiz *releaseWizard) chooseVersion(currentTag string, prs []*github.PullRequest, exists tagExistsFunc) (string, error) {
+	candidates, err := versionCandidates(currentTag, prs, exists)
+	if err != nil && !errors.Is(err, errInvalidSemanticVersioningTag) {
+		return "", err
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(wiz.out, "Current tag: %s\nUnreleased pull requests: %d\n", displayTag(currentTag), len(prs))
+		version, err := wiz.ask("Version: ")
+		if err != nil {
+			return "", err
+		}
+		if version == "" {
+			return "", errReleaseAborted
+		}
+		return version, nil
+	}
+
+	_, _ = fmt.Fprintf(wiz.out, "Current tag: %s\nUnreleased pull requests: %d\n\n", currentTag, len(prs))
+	defaultChoice := 0
+	for i, c := range candidates {
+		mark := ""
+		if c.suggested {
+			mark = " (suggested)"
+			defaultChoice = i + 1
+		}
+		_, _ = fmt.Fprintf(wiz.out, "  %d) %-10s %s%s\n", i+1, c.label, c.tag, mark)
+		for _, pr := range c.prs {
+			_, _ = fmt.Fprintf(wiz.out, "       - %s (#%d)\n", pr.GetTitle(), pr.GetNumber())
+		}
+	}
+
+	question := "Select a version: "
+	if defaultChoice > 0 {
+		question = fmt.Sprintf("Select a version [%d]: ", defaultChoice)
+	}
+	answer, err := wiz.ask("\n" + question)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" && defaultChoice > 0 {
+		return candidates[defaultChoice-1].tag, nil
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(candidates) {
+		return "", fmt.Errorf("%s: invalid choice", answer)
+	}
+	return candidates[n-1].tag, nil
+}
+
+// editBody opens the body in the editor and returns the edited body
+func (wiz *releaseWizard) editBody(body string) (string, error) {
+	f, err := ioutil.TempFile("", "mikku-release-*.md")
+	if err != nil {
+		return "", fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(body); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("close temporary file: %w", err)
+	}
+
+	if err := wiz.editor(f.Name()); err != nil {
+		return "", fmt.Errorf("run editor: %w", err)
+	}
+
+	edited, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("read temporary file: %w", err)
+	}
+	return string(edited), nil
+}
+
+// confirmRelease shows the release and asks whether to create it
+func (wiz *releaseWizard) confirmRelease(repo, newTag, body string, changes []*fileChange) (bool, error) {
+	_, _ = fmt.Fprintf(wiz.out, "\nRepository: %s\nTag: %s\n", repo, newTag)
+	for _, c := range changes {
+		_, _ = fmt.Fprintf(wiz.out, "Commit: %s\n", c.path)
+	}
+	_, _ = fmt.Fprintf(wiz.out, "\n%s\n\n", strings.TrimSpace(body))
+	return confirm(wiz.in, wiz.out, "Create the release? [y/N] ")
+}
+
+// ask prints the question and returns the trimmed answer
+func (wiz *releaseWizard) ask(question string) (string, error) {
+	_, _ = fmt.Fprint(wiz.out, question)
+	answer, err := wiz.in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read answer: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+func displayTag(tag string) string {
+	if tag == "" {
+		return "(none)"
+	}
+	return tag
+}
+
+// runEditor opens the file in $EDITOR. If it is not set, vi is used.
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{defaultEditor}
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
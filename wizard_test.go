@@ -0,0 +1,136 @@
+package mikku
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/v32/github"
+)
+
+// existingTags returns tagExistsFunc of the tags
+func existingTags(tags ...string) tagExistsFunc {
+	return func(tag string) (bool, error) {
+		for _, t := range tags {
+			if t == tag {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func Test_versionCandidates(t *testing.T) {
+	t.Parallel()
+
+	feature := &github.PullRequest{Number: github.Int(1), Title: github.String("feat: add wizard")}
+	fix := &github.PullRequest{Number: github.Int(2), Title: github.String("fix: typo")}
+
+	tests := []struct {
+		name       string
+		currentTag string
+		tags       []string
+		want       []string
+	}{
+		{
+			name:       "release",
+			currentTag: "v1.2.3",
+			want:       []string{"major v2.0.0 false 0", "minor v1.3.0 true 1", "patch v1.2.4 false 1", "prerelease v1.3.0-rc.1 false 0"},
+		},
+		{
+			name:       "pre-releases are published",
+			currentTag: "v1.2.3",
+			tags:       []string{"v1.3.0-rc.1", "v1.3.0-rc.2"},
+			want:       []string{"major v2.0.0 false 0", "minor v1.3.0 true 1", "patch v1.2.4 false 1", "prerelease v1.3.0-rc.3 false 0"},
+		},
+		{
+			name:       "prerelease",
+			currentTag: "v1.3.0-rc.1",
+			tags:       []string{"v1.3.0-rc.1"},
+			want:       []string{"major v2.0.0 false 0", "minor v1.4.0 true 1", "patch v1.3.1 false 1", "prerelease v1.3.0-rc.2 false 0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := versionCandidates(tt.currentTag, []*github.PullRequest{feature, fix}, existingTags(tt.tags...))
+			if err != nil {
+				t.Fatalf("versionCandidates() error = %v", err)
+			}
+			got := make([]string, 0, len(candidates))
+			for _, c := range candidates {
+				got = append(got, fmt.Sprintf("%s %s %v %d", c.label, c.tag, c.suggested, len(c.prs)))
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("versionCandidates() diff=%s", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func Test_releaseWizard_chooseVersion(t *testing.T) {
+	t.Parallel()
+
+	prs := []*github.PullRequest{{Number: github.Int(1), Title: github.String("fix: typo")}}
+
+	tests := []struct {
+		name       string
+		currentTag string
+		tags       []string
+		answer     string
+		want       string
+		wantErr    bool
+	}{
+		{name: "default", currentTag: "v1.2.3", answer: "\n", want: "v1.2.4", wantErr: false},
+		{name: "prerelease after published rc", currentTag: "v1.2.3", tags: []string{"v1.2.4-rc.1"}, answer: "4\n", want: "v1.2.4-rc.2", wantErr: false},
+		{name: "major", currentTag: "v1.2.3", answer: "1\n", want: "v2.0.0", wantErr: false},
+		{name: "invalid choice", currentTag: "v1.2.3", answer: "9\n", want: "", wantErr: true},
+		{name: "first release", currentTag: "", answer: "v0.1.0\n", want: "v0.1.0", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wiz := &releaseWizard{in: bufio.NewReader(strings.NewReader(tt.answer)), out: ioutil.Discard}
+			got, err := wiz.chooseVersion(tt.currentTag, prs, existingTags(tt.tags...))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("chooseVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("chooseVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_releaseWizard_editBodyAndConfirm(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	wiz := &releaseWizard{
+		in:  bufio.NewReader(strings.NewReader("y\n")),
+		out: out,
+		editor: func(path string) error {
+			return ioutil.WriteFile(path, []byte("## Highlights\n"), 0644)
+		},
+	}
+
+	body, err := wiz.editBody("## Changelog\n")
+	if err != nil {
+		t.Fatalf("editBody() error = %v", err)
+	}
+	if body != "## Highlights\n" {
+		t.Errorf("editBody() = %q, want %q", body, "## Highlights\n")
+	}
+
+	ok, err := wiz.confirmRelease("test-repo", "v1.0.0", body, []*fileChange{{path: "CHANGELOG.md"}})
+	if err != nil || !ok {
+		t.Errorf("confirmRelease() = %v, %v, want true", ok, err)
+	}
+	want := "\nRepository: test-repo\nTag: v1.0.0\nCommit: CHANGELOG.md\n\n## Highlights\n\nCreate the release? [y/N] "
+	if out.String() != want {
+		t.Errorf("confirmRelease() output diff=%s", cmp.Diff(out.String(), want))
+	}
+}
@@ -0,0 +1,221 @@
+package mikku
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// errYAMLPathNotFound represents error that the path does not found in the YAML document
+	errYAMLPathNotFound = errors.New("yaml path not found")
+)
+
+// findYAMLScalar finds the scalar node at the dot-separated path such as `image.tag` or `images.0.newTag`
+// JSON documents are also accepted because JSON is a subset of YAML.
+func findYAMLScalar(content []byte, path string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, errYAMLPathNotFound)
+	}
+
+	node := doc.Content[0]
+	for _, key := range strings.Split(path, ".") {
+		child, err := lookupYAMLChild(node, key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		node = child
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%s is not a scalar value", path)
+	}
+	return node, nil
+}
+
+func lookupYAMLChild(node *yaml.Node, key string) (*yaml.Node, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1], nil
+			}
+		}
+	case yaml.SequenceNode:
+		idx, err := strconv.Atoi(key)
+		if err == nil && idx >= 0 && idx < len(node.Content) {
+			return node.Content[idx], nil
+		}
+	}
+	return nil, errYAMLPathNotFound
+}
+
+// replaceYAMLScalar replaces the scalar node with the value in the original content
+// Unlike re-encoding the whole document, comments, indents and key order are preserved.
+func replaceYAMLScalar(content []byte, node *yaml.Node, value string) ([]byte, error) {
+	return replaceYAMLScalarToken(content, node, value, value)
+}
+
+// replaceYAMLString replaces the scalar node with the value which should be read as a string
+// Plain values such as 1.10 are quoted, otherwise they would be read as the float 1.1.
+func replaceYAMLString(content []byte, node *yaml.Node, value string) ([]byte, error) {
+	return replaceYAMLScalarToken(content, node, value, yamlScalarToken(value))
+}
+
+// replaceYAMLScalarToken writes plain as it is if the node is not quoted
+func replaceYAMLScalarToken(content []byte, node *yaml.Node, value, plain string) ([]byte, error) {
+	start, err := yamlNodeOffset(content, node)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := yamlScalarEnd(content, start, node)
+	if err != nil {
+		return nil, err
+	}
+
+	var token string
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		token = strconv.Quote(value)
+	case yaml.SingleQuotedStyle:
+		token = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	default:
+		token = plain
+	}
+
+	replaced := make([]byte, 0, len(content)-(end-start)+len(token))
+	replaced = append(replaced, content[:start]...)
+	replaced = append(replaced, token...)
+	replaced = append(replaced, content[end:]...)
+	return replaced, nil
+}
+
+// yamlNodeOffset converts the line and the column of the node to the byte offset
+func yamlNodeOffset(content []byte, node *yaml.Node) (int, error) {
+	offset := 0
+	for line := 1; line < node.Line; line++ {
+		idx := strings.IndexByte(string(content[offset:]), '\n')
+		if idx < 0 {
+			return 0, fmt.Errorf("line %d is out of range", node.Line)
+		}
+		offset += idx + 1
+	}
+
+	// The column is counted by characters, not bytes
+	col := 1
+	for _, r := range string(content[offset:]) {
+		if col == node.Column {
+			return offset, nil
+		}
+		if r == '\n' {
+			break
+		}
+		offset += len(string(r))
+		col++
+	}
+	return 0, fmt.Errorf("column %d in line %d is out of range", node.Column, node.Line)
+}
+
+func yamlScalarEnd(content []byte, start int, node *yaml.Node) (int, error) {
+	rest := string(content[start:])
+
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		for i := 1; i < len(rest); i++ {
+			switch rest[i] {
+			case '\\':
+				i++
+			case '"':
+				return start + i + 1, nil
+			}
+		}
+	case yaml.SingleQuotedStyle:
+		for i := 1; i < len(rest); i++ {
+			if rest[i] != '\'' {
+				continue
+			}
+			if i+1 < len(rest) && rest[i+1] == '\'' {
+				i++
+				continue
+			}
+			return start + i + 1, nil
+		}
+	case 0:
+		if strings.HasPrefix(rest, node.Value) {
+			return start + len(node.Value), nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported scalar style at line %d", node.Line)
+}
+
+// yamlLastLine returns the last line of the node and its descendants
+func yamlLastLine(node *yaml.Node) int {
+	last := node.Line
+	for _, child := range node.Content {
+		if l := yamlLastLine(child); l > last {
+			last = l
+		}
+	}
+	return last
+}
+
+// insertYAMLLines inserts the lines after the line in the original content
+// If line is 0, the lines are inserted at the beginning.
+func insertYAMLLines(content []byte, line int, lines []string) []byte {
+	offset := 0
+	for l := 0; l < line && offset < len(content); l++ {
+		idx := strings.IndexByte(string(content[offset:]), '\n')
+		if idx < 0 {
+			offset = len(content)
+			break
+		}
+		offset += idx + 1
+	}
+
+	prefix := string(content[:offset])
+	if prefix != "" && !strings.HasSuffix(prefix, "\n") {
+		prefix += "\n"
+	}
+	return []byte(prefix + strings.Join(lines, "\n") + "\n" + string(content[offset:]))
+}
+
+// removeYAMLLine removes the line from the original content
+func removeYAMLLine(content []byte, line int) []byte {
+	start := 0
+	for l := 1; l < line; l++ {
+		idx := strings.IndexByte(string(content[start:]), '\n')
+		if idx < 0 {
+			return content
+		}
+		start += idx + 1
+	}
+
+	end := len(content)
+	if idx := strings.IndexByte(string(content[start:]), '\n'); idx >= 0 {
+		end = start + idx + 1
+	}
+	removed := make([]byte, 0, len(content)-(end-start))
+	removed = append(removed, content[:start]...)
+	return append(removed, content[end:]...)
+}
+
+// yamlScalarToken returns the value as a YAML scalar, quoted only if it would be parsed as a non-string
+func yamlScalarToken(value string) string {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return strconv.Quote(value)
+	}
+	return strings.TrimSuffix(string(b), "\n")
+}
@@ -0,0 +1,76 @@
+package mikku
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_replaceYAMLScalar(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		path    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "plain scalar with comment",
+			content: "apiVersion: v2\nname: app\nversion: 1.0.0 # chart version\nappVersion: v1.0.0\n",
+			path:    "version",
+			value:   "1.1.0",
+			want:    "apiVersion: v2\nname: app\nversion: 1.1.0 # chart version\nappVersion: v1.0.0\n",
+		},
+		{
+			name:    "double quoted nested scalar",
+			content: "image:\n  repository: app\n  tag: \"v1.0.0\"\n",
+			path:    "image.tag",
+			value:   "v1.1.0",
+			want:    "image:\n  repository: app\n  tag: \"v1.1.0\"\n",
+		},
+		{
+			name:    "single quoted scalar in sequence",
+			content: "images:\n  - name: app\n    newTag: 'v1.0.0'\n",
+			path:    "images.0.newTag",
+			value:   "v1.1.0",
+			want:    "images:\n  - name: app\n    newTag: 'v1.1.0'\n",
+		},
+		{
+			name:    "json",
+			content: "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"private\": true\n}\n",
+			path:    "version",
+			value:   "1.1.0",
+			want:    "{\n  \"name\": \"app\",\n  \"version\": \"1.1.0\",\n  \"private\": true\n}\n",
+		},
+		{
+			name:    "path not found",
+			content: "image:\n  tag: v1.0.0\n",
+			path:    "image.digest",
+			value:   "v1.1.0",
+			wantErr: errYAMLPathNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := findYAMLScalar([]byte(tt.content), tt.path)
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("findYAMLScalar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := replaceYAMLScalar([]byte(tt.content), node, tt.value)
+			if err != nil {
+				t.Errorf("replaceYAMLScalar() error = %v", err)
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("replaceYAMLScalar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
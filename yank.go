@@ -0,0 +1,190 @@
+package mikku
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	yankedTitlePrefix = "[YANKED] "
+	yankedBodyHeader  = "> **Warning**\n> This release was yanked and should not be used."
+)
+
+var (
+	errYankAborted = errors.New("yank was aborted")
+)
+
+// YankOptions represents optional settings of `mikku yank` command
+type YankOptions struct {
+	// Delete deletes the release and the tag. Otherwise, the release is marked as yanked.
+	Delete bool
+	// RepointLatest makes the previous release the latest one by marking the yanked release as a pre-release
+	RepointLatest bool
+	// Yes skips the confirmation prompt
+	Yes bool
+	// Reason is written in the yanked release and the audit log
+	Reason string
+	// RequestTimeout is the timeout of each GitHub API call. If it is zero, there is no timeout.
+	RequestTimeout time.Duration
+}
+
+// Yank is the entry point of `mikku yank` command
+// The result is appended to the audit log whether it succeeds or not.
+func Yank(ctx context.Context, repo, tag string, opts YankOptions) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return fmt.Errorf("yank: %w", err)
+	}
+	// Deleted releases can't be restored, so they are never deleted without a record
+	auditLog, err := auditLogPath(cfg)
+	if err != nil {
+		if opts.Delete {
+			return fmt.Errorf("yank: %w: %v", errNoAuditLog, err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "The operation is not recorded in the audit log: %v\n", err)
+	}
+
+	if !opts.Yes {
+		ok, err := confirm(os.Stdin, os.Stdout, yankPrompt(repo, tag, opts))
+		if err != nil {
+			return fmt.Errorf("yank: %w", err)
+		}
+		if !ok {
+			return errYankAborted
+		}
+	}
+
+	svc := newGitHubClientUsingEnv(cfg.GitHubOwner, cfg.GitHubAccessToken, opts.RequestTimeout)
+
+	latest, yankErr := yank(ctx, svc, repo, tag, opts)
+
+	entry := &auditEntry{
+		Time:       time.Now(),
+		Action:     "yank",
+		Repository: repo,
+		Tag:        tag,
+		Details: map[string]string{
+			"delete":        strconv.FormatBool(opts.Delete),
+			"repointLatest": strconv.FormatBool(opts.RepointLatest),
+			"reason":        opts.Reason,
+		},
+	}
+	if yankErr != nil {
+		entry.Error = yankErr.Error()
+	}
+	if err := appendAuditLog(auditLog, entry); err != nil {
+		if yankErr != nil {
+			return fmt.Errorf("yank: %w (failed to record audit log: %v)", yankErr, err)
+		}
+		return fmt.Errorf("yank: %s was yanked but failed to record audit log: %w", tag, err)
+	}
+	if yankErr != nil {
+		return fmt.Errorf("yank: %w", yankErr)
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "%s was yanked.\n", tag)
+	if latest != nil {
+		_, _ = fmt.Fprintf(os.Stdout, "The latest release is %s now.\n", latest.GetTagName())
+	}
+	return nil
+}
+
+// yank deletes or marks the release of the tag
+// If the latest release may have changed, it returns the new latest release. It is nil if no release is left.
+func yank(ctx context.Context, svc *githubClient, repo, tag string, opts YankOptions) (*github.RepositoryRelease, error) {
+	rel, err := svc.getReleaseByTag(ctx, repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("get release: %w", err)
+	}
+
+	if opts.Delete {
+		if err := svc.deleteRelease(ctx, repo, rel.GetID()); err != nil {
+			return nil, fmt.Errorf("delete release: %w", err)
+		}
+		if err := svc.deleteTag(ctx, repo, tag); err != nil {
+			return nil, fmt.Errorf("delete tag (release was already deleted): %w", err)
+		}
+	} else {
+		edit := &github.RepositoryRelease{
+			Name: github.String(yankedTitle(rel)),
+			Body: github.String(yankedBody(rel.GetBody(), opts.Reason)),
+		}
+		// GitHub doesn't choose pre-releases as the latest release
+		if opts.RepointLatest {
+			edit.Prerelease = github.Bool(true)
+		}
+		if _, err := svc.editRelease(ctx, repo, rel.GetID(), edit); err != nil {
+			return nil, fmt.Errorf("mark release as yanked: %w", err)
+		}
+	}
+
+	if !opts.Delete && !opts.RepointLatest {
+		return nil, nil
+	}
+	latest, err := svc.getLatestRelease(ctx, repo)
+	if err != nil {
+		if errors.Is(err, errReleaseNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get latest release: %w", err)
+	}
+	return latest, nil
+}
+
+// yankedTitle returns the release title with the yanked prefix
+func yankedTitle(rel *github.RepositoryRelease) string {
+	name := rel.GetName()
+	if name == "" {
+		name = rel.GetTagName()
+	}
+	if strings.HasPrefix(name, yankedTitlePrefix) {
+		return name
+	}
+	return yankedTitlePrefix + name
+}
+
+// yankedBody prepends the warning to the release body
+// The warning is not duplicated if the release was already yanked.
+func yankedBody(body, reason string) string {
+	if strings.HasPrefix(body, yankedBodyHeader) {
+		return body
+	}
+	warning := yankedBodyHeader
+	if reason != "" {
+		warning += "\n> Reason: " + reason
+	}
+	return warning + "\n\n" + body
+}
+
+func yankPrompt(repo, tag string, opts YankOptions) string {
+	action := "mark the release as yanked"
+	if opts.Delete {
+		action = "delete the release and the tag"
+	}
+	return fmt.Sprintf("Yank %s of %s: %s. Continue? [y/N] ", tag, repo, action)
+}
+
+// confirm asks the question and reports whether the answer is yes
+func confirm(r io.Reader, w io.Writer, question string) (bool, error) {
+	_, _ = fmt.Fprint(w, question)
+
+	answer, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("read answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
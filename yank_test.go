@@ -0,0 +1,142 @@
+package mikku
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_yank(t *testing.T) {
+	t.Parallel()
+
+	release := &github.RepositoryRelease{
+		ID:      github.Int64(2),
+		TagName: github.String("v1.0.1"),
+		Name:    github.String("v1.0.1"),
+		Body:    github.String("## Changelog\n"),
+	}
+	previous := &github.RepositoryRelease{ID: github.Int64(1), TagName: github.String("v1.0.0")}
+
+	tests := []struct {
+		name       string
+		opts       YankOptions
+		injector   func(*MockgitHubRepositoriesClient, *MockgitHubGitClient)
+		wantLatest string
+		wantErr    bool
+	}{
+		{
+			name: "mark as yanked",
+			opts: YankOptions{Reason: "broken build"},
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(release, nil, nil)
+				repoCli.EXPECT().EditRelease(gomock.Any(), "test-owner", "test-repo", int64(2), &github.RepositoryRelease{
+					Name: github.String("[YANKED] v1.0.1"),
+					Body: github.String("> **Warning**\n> This release was yanked and should not be used.\n> Reason: broken build\n\n## Changelog\n"),
+				}).Return(release, nil, nil)
+			},
+			wantLatest: "",
+			wantErr:    false,
+		},
+		{
+			name: "mark as yanked and repoint latest",
+			opts: YankOptions{RepointLatest: true},
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(release, nil, nil)
+				repoCli.EXPECT().EditRelease(gomock.Any(), "test-owner", "test-repo", int64(2), &github.RepositoryRelease{
+					Name:       github.String("[YANKED] v1.0.1"),
+					Body:       github.String("> **Warning**\n> This release was yanked and should not be used.\n\n## Changelog\n"),
+					Prerelease: github.Bool(true),
+				}).Return(release, nil, nil)
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").Return(previous, nil, nil)
+			},
+			wantLatest: "v1.0.0",
+			wantErr:    false,
+		},
+		{
+			name: "delete release and tag",
+			opts: YankOptions{Delete: true},
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(release, nil, nil)
+				repoCli.EXPECT().DeleteRelease(gomock.Any(), "test-owner", "test-repo", int64(2)).Return(nil, nil)
+				gitCli.EXPECT().DeleteRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(nil, nil)
+				repoCli.EXPECT().GetLatestRelease(gomock.Any(), "test-owner", "test-repo").Return(previous, nil, nil)
+			},
+			wantLatest: "v1.0.0",
+			wantErr:    false,
+		},
+		{
+			name: "failed to delete tag",
+			opts: YankOptions{Delete: true},
+			injector: func(repoCli *MockgitHubRepositoriesClient, gitCli *MockgitHubGitClient) {
+				repoCli.EXPECT().GetReleaseByTag(gomock.Any(), "test-owner", "test-repo", "v1.0.1").Return(release, nil, nil)
+				repoCli.EXPECT().DeleteRelease(gomock.Any(), "test-owner", "test-repo", int64(2)).Return(nil, nil)
+				gitCli.EXPECT().DeleteRef(gomock.Any(), "test-owner", "test-repo", "tags/v1.0.1").Return(nil, errors.New("server error"))
+			},
+			wantLatest: "",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repoCli := NewMockgitHubRepositoriesClient(ctrl)
+			gitCli := NewMockgitHubGitClient(ctrl)
+			tt.injector(repoCli, gitCli)
+
+			svc := newGitHubClient("test-owner", gitHubClients{repositories: repoCli, git: gitCli})
+			got, err := yank(context.Background(), svc, "test-repo", "v1.0.1", tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("yank() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.GetTagName() != tt.wantLatest {
+				t.Errorf("yank() latest = %s, want %s", got.GetTagName(), tt.wantLatest)
+			}
+		})
+	}
+}
+
+func Test_yankedBody(t *testing.T) {
+	t.Parallel()
+
+	once := yankedBody("## Changelog\n", "")
+	if twice := yankedBody(once, "again"); twice != once {
+		t.Errorf("yankedBody() = %q, want %q", twice, once)
+	}
+}
+
+func Test_confirm(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		answer string
+		want   bool
+	}{
+		{name: "yes", answer: "y\n", want: true},
+		{name: "yes without newline", answer: "YES", want: true},
+		{name: "no", answer: "n\n", want: false},
+		{name: "empty", answer: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			got, err := confirm(strings.NewReader(tt.answer), w, "Continue? [y/N] ")
+			if err != nil {
+				t.Fatalf("confirm() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("confirm() = %v, want %v", got, tt.want)
+			}
+			if w.String() != "Continue? [y/N] " {
+				t.Errorf("confirm() question = %q", w.String())
+			}
+		})
+	}
+}